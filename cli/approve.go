@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"strings"
+	"wallet/sol"
+	"wallet/token"
+)
+
+//无限授权额度, 即uint256最大值
+var unlimitedAllowance = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+var (
+	//地址参数不是合法的以太坊地址
+	ErrInvalidAddress = errors.New("invalid address")
+	//授权额度不足
+	ErrInsufficientAllowance = errors.New("insufficient allowance")
+	//代币余额不足
+	ErrInsufficientBalance = errors.New("insufficient token balance")
+)
+
+//解析地址参数, what用于错误提示
+func parseAddress(s, what string) (common.Address, error) {
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("%w for %s: %q", ErrInvalidAddress, what, s)
+	}
+	return common.HexToAddress(s), nil
+}
+
+//解析授权数量, unlimited或max表示无限授权, 否则按代币的decimals换算
+func parseAllowance(amount string, decimals uint8) (*big.Int, error) {
+	switch strings.ToLower(strings.TrimSpace(amount)) {
+	case "unlimited", "max":
+		return new(big.Int).Set(unlimitedAllowance), nil
+	}
+	return parseDecimal(amount, int(decimals))
+}
+
+//带单位的授权额度, 无限授权显示为unlimited
+func formatAllowance(v *big.Int, info *token.Info) string {
+	if v.Cmp(unlimitedAllowance) == 0 {
+		return "unlimited " + info.Symbol
+	}
+	return formatToken(v, info)
+}
+
+//授权spender从from账户转出代币, amount为unlimited时无限授权
+func (c CmdClient) approve(from, spender, tokenSpec, amount string, chainID int64, overrides feeOverrides) (*types.Transaction, error) {
+	return c.setAllowance(from, spender, tokenSpec, amount, false, chainID, overrides)
+}
+
+//撤销spender的授权, 即把授权额度设为0; 额度已为0时不发送交易, 返回nil
+func (c CmdClient) revoke(from, spender, tokenSpec string, chainID int64, overrides feeOverrides) (*types.Transaction, error) {
+	return c.setAllowance(from, spender, tokenSpec, "0", true, chainID, overrides)
+}
+
+//approve与revoke的公共流程: 查询当前额度, 打印风险提示后发送approve交易
+func (c CmdClient) setAllowance(from, spender, tokenSpec, amount string, revoke bool, chainID int64, overrides feeOverrides) (*types.Transaction, error) {
+	owner, err := parseAddress(from, "-from")
+	if err != nil {
+		return nil, err
+	}
+	spenderAddr, err := parseAddress(spender, "-spender")
+	if err != nil {
+		return nil, err
+	}
+	rpccli, cli, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+	info, err := c.resolveToken(context.Background(), cli, tokenSpec)
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseAllowance(amount, info.Decimals)
+	if err != nil {
+		return nil, err
+	}
+	erc20, err := sol.NewERC20(info.Address, cli)
+	if err != nil {
+		return nil, err
+	}
+	current, err := erc20.Allowance(&bind.CallOpts{}, owner, spenderAddr)
+	if err != nil {
+		return nil, err
+	}
+	if revoke && current.Sign() == 0 {
+		fmt.Printf("allowance of %s from %s is already 0, nothing to revoke\n", spenderAddr.Hex(), owner.Hex())
+		return nil, nil
+	}
+	fmt.Printf("owner: %s, spender: %s, allowance: %s -> %s\n", owner.Hex(), spenderAddr.Hex(), formatAllowance(current, info), formatAllowance(value, info))
+	warnApproval(erc20, info, spenderAddr, current, value)
+	return c.transactToken(rpccli, cli, info, owner.Hex(), chainID, overrides, "approve", spenderAddr, value)
+}
+
+//授权风险提示: 无限授权、超过总供应量的授权, 以及直接修改非零额度可能被抢先使用旧额度
+func warnApproval(erc20 *sol.ERC20, info *token.Info, spender common.Address, current, value *big.Int) {
+	if value.Cmp(unlimitedAllowance) == 0 {
+		fmt.Printf("warning: unlimited approval, %s can transfer ALL of your %s at any time until revoked\n", spender.Hex(), info.Symbol)
+	} else if supply, err := erc20.TotalSupply(&bind.CallOpts{}); err == nil && value.Cmp(supply) > 0 {
+		fmt.Printf("warning: approval exceeds the total supply (%s), it is effectively unlimited\n", formatToken(supply, info))
+	}
+	if current.Sign() != 0 && value.Sign() != 0 {
+		fmt.Printf("warning: changing a non-zero allowance lets %s spend both the old and the new allowance if it front-runs this transaction, consider 'revoke' first\n", spender.Hex())
+	}
+}
+
+//查询owner授权给spender的额度
+func (c CmdClient) allowance(ownerAddr, spender, tokenSpec string) (*big.Int, error) {
+	owner, err := parseAddress(ownerAddr, "-owner")
+	if err != nil {
+		return nil, err
+	}
+	spenderAddr, err := parseAddress(spender, "-spender")
+	if err != nil {
+		return nil, err
+	}
+	_, cli, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+	info, err := c.resolveToken(context.Background(), cli, tokenSpec)
+	if err != nil {
+		return nil, err
+	}
+	erc20, err := sol.NewERC20(info.Address, cli)
+	if err != nil {
+		return nil, err
+	}
+	value, err := erc20.Allowance(&bind.CallOpts{}, owner, spenderAddr)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("allowance of %s from %s is: %s\n", spenderAddr.Hex(), owner.Hex(), formatAllowance(value, info))
+	return value, nil
+}
+
+//spender使用授权额度把from账户的代币转给to, 发送前检查额度与余额
+func (c CmdClient) transferFrom(spender, from, toaddr, tokenSpec, amount string, chainID int64, overrides feeOverrides) (*types.Transaction, error) {
+	spenderAddr, err := parseAddress(spender, "-spender")
+	if err != nil {
+		return nil, err
+	}
+	owner, err := parseAddress(from, "-from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseAddress(toaddr, "-to")
+	if err != nil {
+		return nil, err
+	}
+	rpccli, cli, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+	info, err := c.resolveToken(context.Background(), cli, tokenSpec)
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseDecimal(amount, int(info.Decimals))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("spender: %s, from: %s, to: %s, value: %s\n", spenderAddr.Hex(), owner.Hex(), to.Hex(), formatToken(value, info))
+	erc20, err := sol.NewERC20(info.Address, cli)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := erc20.Allowance(&bind.CallOpts{}, owner, spenderAddr)
+	if err != nil {
+		return nil, err
+	}
+	if allowed.Cmp(value) < 0 {
+		return nil, fmt.Errorf("%w: %s allowed, %s requested", ErrInsufficientAllowance, formatAllowance(allowed, info), formatToken(value, info))
+	}
+	balance, err := erc20.BalanceOf(&bind.CallOpts{}, owner)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Cmp(value) < 0 {
+		return nil, fmt.Errorf("%w: %s has %s, %s requested", ErrInsufficientBalance, owner.Hex(), formatToken(balance, info), formatToken(value, info))
+	}
+	return c.transactToken(rpccli, cli, info, spenderAddr.Hex(), chainID, overrides, "transferFrom", owner, to, value)
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/howeyc/gopass"
+	"golang.org/x/text/unicode/norm"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+)
+
+//抽查的单词个数与允许的尝试次数
+const (
+	quizWords    = 3
+	quizAttempts = 3
+)
+
+//助记词抽查未通过
+var ErrBackupNotVerified = errors.New("mnemonic backup not verified")
+
+//清屏并将光标移到左上角, 同时清除终端的回滚缓冲区, 避免向上滚动仍能看到助记词
+func clearScreen() {
+	fmt.Print("\033[H\033[2J\033[3J")
+}
+
+//在清屏后的区域中展示一次助记词, 用户抄写后再清屏并随机抽查若干位置的单词
+func backupMnemonic(mne string) error {
+	words := strings.Fields(mne)
+	//1. 展示助记词
+	clearScreen()
+	fmt.Println("Write down your mnemonic and keep it safe, it will NOT be shown again:")
+	fmt.Println()
+	for i, w := range words {
+		fmt.Printf("%2d. %s\n", i+1, w)
+	}
+	fmt.Println()
+	fmt.Println("Press Enter when you have written it down...")
+	if _, err := gopass.GetPasswd(); err != nil {
+		return err
+	}
+	clearScreen()
+	//2. 抽查
+	for attempt := 0; attempt < quizAttempts; attempt++ {
+		positions, err := randomPositions(len(words), quizWords)
+		if err != nil {
+			return err
+		}
+		ok, err := quizMnemonic(words, positions)
+		if err != nil {
+			return err
+		}
+		if ok {
+			fmt.Println("Mnemonic backup verified")
+			return nil
+		}
+		fmt.Println("Wrong word, please check your backup and try again")
+	}
+	return ErrBackupNotVerified
+}
+
+//依次询问positions位置上的单词, 全部正确时返回true
+func quizMnemonic(words []string, positions []int) (bool, error) {
+	for _, pos := range positions {
+		fmt.Printf("Please input word #%d: \n", pos+1)
+		answer, err := gopass.GetPasswd()
+		if err != nil {
+			return false, err
+		}
+		//单词表为NFKD形式, 输入法可能给出组合形式
+		if norm.NFKD.String(strings.TrimSpace(string(answer))) != norm.NFKD.String(words[pos]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+//从[0, n)中随机选取k个不同位置, 按升序返回
+func randomPositions(n, k int) ([]int, error) {
+	if k > n {
+		k = n
+	}
+	chosen := make(map[int]bool, k)
+	positions := make([]int, 0, k)
+	for len(positions) < k {
+		r, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+		if err != nil {
+			return nil, err
+		}
+		pos := int(r.Int64())
+		if chosen[pos] {
+			continue
+		}
+		chosen[pos] = true
+		positions = append(positions, pos)
+	}
+	sort.Ints(positions)
+	return positions, nil
+}
+
+//将助记词写入权限为0600的新文件, 文件已存在时报错而不覆盖
+func writeMnemonicFile(filename, mne string) error {
+	if err := writeSecretFile(filename, []byte(mne+"\n")); err != nil {
+		return err
+	}
+	fmt.Println("Mnemonic written to: ", filename)
+	return nil
+}
+
+//将敏感内容写入权限为0600的新文件, 文件已存在时报错而不覆盖
+func writeSecretFile(filename string, content []byte) error {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		_ = f.Close()
+		_ = os.Remove(filename)
+		return err
+	}
+	return f.Close()
+}
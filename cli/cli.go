@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/ethereum/go-ethereum"
@@ -10,11 +11,16 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"log"
+	"github.com/howeyc/gopass"
 	"math/big"
 	"os"
+	"sort"
 	"strings"
+	"wallet/config"
+	"wallet/hdkeystore"
 	"wallet/hdwallet"
+	"wallet/nonce"
+	"wallet/password"
 	"wallet/sol"
 )
 
@@ -25,125 +31,362 @@ type CmdClient struct {
 	network string
 	//keystore文件路径
 	dataDir string
+	//口令来源, 默认在终端提示输入
+	passwords password.Provider
+	//配置文件路径与当前网络, 由Run根据全局参数设置
+	configFile  string
+	networkName string
+	profile     *config.Network
 }
 
+//network与datadir为默认值, Run时会被配置文件与全局参数覆盖
 func NewCmdClient(network, datadir string) *CmdClient {
+	profile := *config.Default().Networks[config.DefaultNetwork]
+	profile.RPC = network
 	return &CmdClient{
-		network: network,
-		dataDir: datadir,
+		network:     network,
+		dataDir:     datadir,
+		passwords:   password.TTY{},
+		configFile:  config.FileName,
+		networkName: config.DefaultNetwork,
+		profile:     &profile,
 	}
 }
 
 func (c CmdClient) Help() {
-	fmt.Println("Usage:")
-	fmt.Println("wallet createwallet -password PASSWORD --for create new wallet")
-	fmt.Println("wallet transfer -from FROMADDR -to TOADDR -value VALUE --for transfer from acct to toaddr")
+	fmt.Println("Usage: wallet [-config FILE] [-network NAME] [-rpc URL] [-datadir DIR] COMMAND [FLAGS]")
+	fmt.Println("\tFILE: JSON config with named networks (rpc, chainId, explorer, tokens), default " + config.FileName)
+	fmt.Println("wallet createwallet [-password PASSWORD] [-words 12|15|18|21|24] [-lang LANGUAGE] [-passphrase] [-backupfile FILE] [KDFFLAGS] [PASSWORDFLAGS] --for create new wallet")
+	fmt.Println("\tLANGUAGE: " + strings.Join(hdwallet.MnemonicLanguages(), ", "))
+	fmt.Println("wallet importmnemonic [-path PATH] [-passphrase] [KDFFLAGS] [PASSWORDFLAGS] --for import wallet from an existing mnemonic (read from prompt)")
+	fmt.Println("wallet deriveaccounts -count N [-account ACCOUNT] [-start INDEX] [-store] [-passphrase] [KDFFLAGS] [PASSWORDFLAGS] --for list (and store) the first N addresses of a mnemonic")
+	fmt.Println("wallet newaccount [PASSWORDFLAGS] --for derive and store the next account from the stored seed")
+	fmt.Println("wallet listaccounts [-balances] --for list accounts in the keystore (with ETH and configured token balances)")
+	fmt.Println("wallet changepassword -address ADDRESS [PASSWORDFLAGS] --for re-encrypt an account with a new password")
+	fmt.Println("wallet importkey [-file KEYFILE] [KDFFLAGS] [PASSWORDFLAGS] --for import a hex private key (read from prompt) or a V3 keystore file")
+	fmt.Println("wallet exportkey -address ADDRESS [-format hex|json] [-out FILE] [KDFFLAGS] [PASSWORDFLAGS] --for export a private key or V3 keystore file")
+	fmt.Println("wallet rekey -address ADDRESS|-all [-force] [KDFFLAGS] [PASSWORDFLAGS] --for re-encrypt keystore files (and the seed vault with -all) with new KDF parameters, -force allows weaker parameters")
+	fmt.Println("\tKDFFLAGS: -kdf light|standard|pbkdf2 (default standard), -scryptn N -scryptp P, -pbkdf2c ITERATIONS")
+	fmt.Println("\tPASSWORDFLAGS: -passwordfile FILE (one password per line, in prompt order), -passwordenv NAME or -passwordstdin; prompt if none given")
+	fmt.Println("wallet transfer -from FROMADDR -to TOADDR -value AMOUNT [-chainid ID] [FEEFLAGS] [WAITFLAGS] [PASSWORDFLAGS] --for transfer from acct to toaddr")
+	fmt.Println("\tAMOUNT: e.g. 1.5ether, 30gwei, 1000 (default unit wei)")
 	fmt.Println("wallet getbalance -from FROMADDR --for get balance")
-	fmt.Println("wallet sendtoken -from FROMADDR -to TOADDR -value VALUE --for send tokens")
-	fmt.Println("wallet tokenbalance -from FROMADDR --for get tokenbalance")
-	fmt.Println("wallet tokendetail -who WHO --for get tokendetail(token transfer records)")
+	fmt.Println("wallet sendtoken [-token TOKEN] -from FROMADDR -to TOADDR -value AMOUNT [-chainid ID] [FEEFLAGS] [WAITFLAGS] [PASSWORDFLAGS] --for send tokens")
+	fmt.Println("\tAMOUNT: token units, e.g. 12.34 (scaled by the token's decimals)")
+	fmt.Println("\tFEEFLAGS: -maxfee GWEI -tip GWEI (EIP-1559) or -gasprice GWEI (legacy), -gaslimit GAS, -gasmultiplier FACTOR")
+	fmt.Println("\tWAITFLAGS: -wait N (wait for N confirmations) -timeout DURATION")
+	fmt.Println("\tTOKEN: contract address or token alias of the network in the config file, default " + defaultToken)
+	fmt.Println("wallet tokenbalance [-token TOKEN] -from FROMADDR --for get tokenbalance")
+	fmt.Println("wallet tokendetail [-token TOKEN] -who WHO [-fromblock BLOCK] [-toblock BLOCK|latest] [-limit N] --for get tokendetail(token transfer records)")
+	fmt.Println("wallet tokeninfo [-token TOKEN] --for get token name, symbol and decimals")
+	fmt.Println("wallet approve [-token TOKEN] -from OWNER -spender SPENDER -value AMOUNT|unlimited [-chainid ID] [FEEFLAGS] [WAITFLAGS] [PASSWORDFLAGS] --for allow spender to transfer tokens of owner")
+	fmt.Println("wallet revoke [-token TOKEN] -from OWNER -spender SPENDER [-chainid ID] [FEEFLAGS] [WAITFLAGS] [PASSWORDFLAGS] --for set the allowance of spender to 0")
+	fmt.Println("wallet allowance [-token TOKEN] -owner OWNER -spender SPENDER --for get the amount spender is allowed to transfer from owner")
+	fmt.Println("wallet transferfrom [-token TOKEN] -spender SPENDER -from OWNER -to TOADDR -value AMOUNT [-chainid ID] [FEEFLAGS] [WAITFLAGS] [PASSWORDFLAGS] --for transfer tokens of owner using the allowance of spender")
+	fmt.Println("wallet deploytoken -from FROMADDR -name TOKEN [-force] [-chainid ID] [FEEFLAGS] [WAITFLAGS] [PASSWORDFLAGS] --for deploy the Lelecoin contract and record it in the config file as TOKEN, the deployer is the admin; with -wait the alias is recorded only after the contract is mined")
+	fmt.Println("wallet mint [-token TOKEN] -from ADMIN -to TOADDR -value AMOUNT [-chainid ID] [FEEFLAGS] [WAITFLAGS] [PASSWORDFLAGS] --for mint Lelecoin tokens, only the admin can mint")
+	fmt.Println("wallet history [-address ADDRESS] [-token TOKEN] [-offline] [-limit N] --for show sent transactions and token transfers/approvals from the local history, synced from the node when reachable")
+	fmt.Println("wallet txstatus -hash TXHASH --for get transaction status, gas used and fee")
+	fmt.Println("wallet speedup -hash TXHASH [-bump PERCENT] [-gaslimit GAS] [WAITFLAGS] [PASSWORDFLAGS] --for resend a pending transaction with a higher fee")
+	fmt.Println("wallet cancel -hash TXHASH [-bump PERCENT] [-gaslimit GAS] [WAITFLAGS] [PASSWORDFLAGS] --for replace a pending transaction with a 0 ETH transfer to self")
 }
 
-//封装钱包创建方法, 该方法需要传入一个口令
-func (c CmdClient) createWallet(pass string) error {
-	w, err := hdwallet.NewHDWallet(c.dataDir)
+//封装钱包创建方法, pass为空时从口令来源读取新口令, words与lang指定助记词单词数与语言
+//usePassphrase为true时提示输入BIP-39 passphrase
+//backupFile为空时在终端展示助记词并抽查, 否则将助记词写入该文件, 备份完成后才写keystore文件
+func (c CmdClient) createWallet(pass string, words int, lang string, usePassphrase bool, backupFile string, kdf hdkeystore.KDF) error {
+	//新助记词必然与已有种子不同, 在展示助记词之前拒绝
+	if hdkeystore.NewHDKeyStoreWithoutKey(c.dataDir).HasSeed() {
+		return fmt.Errorf("%w in %s, use another -datadir", hdwallet.ErrSeedExists, c.dataDir)
+	}
+	passphrase, err := readPassphrase(usePassphrase, true)
+	if err != nil {
+		return err
+	}
+	mne, err := hdwallet.NewMnemonic(words, lang)
+	if err != nil {
+		return err
+	}
+	if backupFile != "" {
+		err = writeMnemonicFile(backupFile, mne)
+	} else {
+		err = backupMnemonic(mne)
+	}
+	if err != nil {
+		return err
+	}
+	w, err := hdwallet.NewHDWalletFromMnemonic(mne, passphrase, "", c.dataDir)
+	if err != nil {
+		return err
+	}
+	if err := w.HDKeystore.SetKDF(kdf); err != nil {
+		return err
+	}
+	if pass == "" {
+		if pass, err = c.newPassword(w.Address.Hex()); err != nil {
+			return err
+		}
+	}
+	if err := w.StoreKey(pass); err != nil {
+		return err
+	}
+	fmt.Println("Created account: ", w.Address.Hex())
+	return nil
+}
+
+//封装助记词导入方法, 助记词与口令均从终端读取, 不经过命令行参数
+func (c CmdClient) importMnemonic(path string, usePassphrase bool, kdf hdkeystore.KDF) error {
+	mne, err := readMnemonic()
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase(usePassphrase, false)
+	if err != nil {
+		return err
+	}
+	w, err := hdwallet.NewHDWalletFromMnemonic(mne, passphrase, path, c.dataDir)
+	if err != nil {
+		return err
+	}
+	if err := w.HDKeystore.SetKDF(kdf); err != nil {
+		return err
+	}
+	pass, err := c.newPassword(w.Address.Hex())
+	if err != nil {
+		return err
+	}
+	if err := w.StoreKey(pass); err != nil {
+		return err
+	}
+	fmt.Println("Imported account: ", w.Address.Hex())
+	return nil
+}
+
+//列出同一助记词推导出的多个地址, store为true时全部以同一口令储存
+func (c CmdClient) deriveAccounts(account, start, count uint, store, usePassphrase bool, kdf hdkeystore.KDF) error {
+	mne, err := readMnemonic()
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase(usePassphrase, false)
+	if err != nil {
+		return err
+	}
+	wallets, err := hdwallet.DeriveHDWallets(mne, passphrase, uint32(account), uint32(start), uint32(count), c.dataDir)
+	if err != nil {
+		return err
+	}
+	for _, w := range wallets {
+		fmt.Printf("%s\t%s\n", w.Path, w.Address.Hex())
+	}
+	if !store {
+		return nil
+	}
+	pass, err := c.newPassword(fmt.Sprintf("%d accounts", len(wallets)))
+	if err != nil {
+		return err
+	}
+	for _, w := range wallets {
+		if err := w.HDKeystore.SetKDF(kdf); err != nil {
+			return err
+		}
+		if err := w.StoreKey(pass); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Stored %d accounts\n", len(wallets))
+	return nil
+}
+
+//由keystore目录中的种子文件推导下一个账户
+func (c CmdClient) newAccount() error {
+	pass, err := c.password("seed vault")
+	if err != nil {
+		return err
+	}
+	w, err := hdwallet.DeriveNextAccount(c.dataDir, pass)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("New account: %s (%s)\n", w.Address.Hex(), w.Path)
+	return nil
+}
+
+//从终端读取助记词, 输入不回显
+func readMnemonic() (string, error) {
+	fmt.Println("Please input mnemonic: ")
+	mne, err := gopass.GetPasswd()
+	if err != nil {
+		return "", err
+	}
+	return string(mne), nil
+}
+
+//从终端读取BIP-39 passphrase, enabled为false时返回空串, confirm为true时要求重复确认
+func readPassphrase(enabled, confirm bool) (string, error) {
+	if !enabled {
+		return "", nil
+	}
+	fmt.Println("Please input BIP-39 passphrase: ")
+	passphrase, err := gopass.GetPasswd()
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		fmt.Println("Please repeat BIP-39 passphrase: ")
+		repeat, err := gopass.GetPasswd()
+		if err != nil {
+			return "", err
+		}
+		if string(passphrase) != string(repeat) {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return string(passphrase), nil
+}
+
+var (
+	//配置的链ID与节点返回的不一致
+	ErrChainIDMismatch = errors.New("chain id mismatch")
+	//无法连接到以太坊节点
+	ErrNodeUnreachable = errors.New("node unreachable")
+	//未知的子命令
+	ErrUnknownCommand = errors.New("unknown command")
+)
+
+//从节点获取链ID, expected不为0时要求与节点一致, 防止交易在其他链上被重放
+func chainIDOf(ethcli *ethclient.Client, expected int64) (*big.Int, error) {
+	chainID, err := ethcli.ChainID(context.Background())
 	if err != nil {
-		log.Panic("Failed to createWallet: ",err)
+		return nil, err
 	}
-	return w.StoreKey(pass)
+	if expected != 0 && chainID.Cmp(big.NewInt(expected)) != 0 {
+		return nil, fmt.Errorf("%w: configured %d, node reports %s", ErrChainIDMismatch, expected, chainID)
+	}
+	return chainID, nil
 }
 
-//transfer方法实现交易全过程
-func (c CmdClient) transfer(from, toaddr string, value int64) error {
+//transfer方法实现交易全过程, chainID为0时使用节点返回的链ID
+func (c CmdClient) transfer(from, toaddr string, value *big.Int, chainID int64, overrides feeOverrides) (*types.Transaction, error) {
 	//1. 钱包加载
-	w, _ := hdwallet.LoadWallet(from, c.dataDir)
+	w, err := hdwallet.LoadWallet(from, c.dataDir, c.passwords)
+	if err != nil {
+		return nil, err
+	}
 	//2. 连接到以太坊节点
-	ethcli, err := ethclient.Dial(c.network)
+	rpccli, ethcli, err := c.dial()
 	if err != nil {
-		log.Panic("Failed to connect Ethereum: ", err)
+		return nil, err
 	}
 	defer ethcli.Close()
-	//3. 获取账户交易的nonce值
-	nonce, _ := ethcli.NonceAt(context.Background(), common.HexToAddress(from), nil)
+	//3. 获取并核对链ID
+	chainIDBig, err := chainIDOf(ethcli, c.chainID(chainID))
+	if err != nil {
+		return nil, err
+	}
+	//获取账户交易的nonce值
+	nonceMgr := nonce.NewManager(c.dataDir)
+	txNonce, err := c.nextNonce(nonceMgr, ethcli, common.HexToAddress(from))
+	if err != nil {
+		return nil, err
+	}
 	//4. 创建未签名的交易
-	gasLimit := uint64(300000)
-	gasPrice := big.NewInt(21000000000)
-	amount := big.NewInt(value)
-	tx := types.NewTransaction(nonce, common.HexToAddress(toaddr), amount, gasLimit, gasPrice, []byte("Salary"))
+	fees, err := suggestFees(context.Background(), rpccli, ethcli, overrides)
+	if err != nil {
+		return nil, err
+	}
+	to := common.HexToAddress(toaddr)
+	data := []byte("Salary")
+	gasLimit, err := fees.estimateGas(context.Background(), ethcli, ethereum.CallMsg{
+		From:  common.HexToAddress(from),
+		To:    &to,
+		Value: value,
+		Data:  data,
+	}, overrides)
+	if err != nil {
+		return nil, err
+	}
+	fees.printEstimate(gasLimit)
+	tx := fees.newTx(chainIDBig, txNonce, to, value, gasLimit, data)
 	//5. 签名
-	signedTx, err := w.HDKeystore.SignTx(common.HexToAddress(from), tx, nil)
+	signedTx, err := w.HDKeystore.SignTx(common.HexToAddress(from), tx, chainIDBig)
 	if err != nil {
-		log.Panic("Failed to SignTx: ", err)
+		return nil, err
 	}
 	//6. 发送交易
-	return ethcli.SendTransaction(context.Background(), signedTx)
+	if err := ethcli.SendTransaction(context.Background(), signedTx); err != nil {
+		return nil, err
+	}
+	return signedTx, nonceMgr.Commit(common.HexToAddress(from), txNonce, signedTx.Hash())
 }
 
-func (c CmdClient) getBalance(from string) (int64, error) {
+//通过nonce管理器获取下一个nonce, 提示被节点丢弃的交易
+func (c CmdClient) nextNonce(nonceMgr *nonce.Manager, ethcli *ethclient.Client, from common.Address) (uint64, error) {
+	txNonce, dropped, err := nonceMgr.Next(context.Background(), ethcli, from)
+	if err != nil {
+		return 0, err
+	}
+	for _, tx := range dropped {
+		fmt.Printf("warning: tx %s (nonce %d) was dropped by the node, its nonce will be reused\n", tx.Hash.Hex(), tx.Nonce)
+	}
+	return txNonce, nil
+}
+
+func (c CmdClient) getBalance(from string) (*big.Int, error) {
 	//1. 连接至以太坊
-	ethcli, err := ethclient.Dial(c.network)
+	_, ethcli, err := c.dial()
 	if err != nil {
-		log.Panic("Failed to connect Ethereum: ", err)
+		return nil, err
 	}
 	defer ethcli.Close()
 	//2. 查询余额
 	addr := common.HexToAddress(from)
 	value, err := ethcli.BalanceAt(context.Background(), addr, nil)
 	if err != nil {
-		log.Panic("Failed to BalanceAt: ", err)
+		return nil, err
 	}
-	return value.Int64(), nil
+	return value, nil
 }
 
-const LelecoinContractAddr = "0x9B4E5A473d60D2D696F82d224723769d25F104c2"
-func (c CmdClient) sendToken(from, toaddr string, value int64) error {
+//发送tokenSpec指定的ERC-20代币, amount按代币的decimals换算
+//chainID为0时使用节点返回的链ID
+func (c CmdClient) sendToken(from, toaddr, tokenSpec, amount string, chainID int64, overrides feeOverrides) (*types.Transaction, error) {
 	//1. 连接以太坊
-	cli, _ := ethclient.Dial(c.network)
+	rpccli, cli, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
 	fmt.Println("connect success")
 	defer cli.Close()
 	//2. 创建Token合约实例, 需要合约地址
-	lelecoin, _ := sol.NewLelecoin(common.HexToAddress(LelecoinContractAddr), cli)
-	//fmt.Println("newlelecoin success")
-	//3. 设置调用身份
-	//3.1 钱包加载
-	w, _ := hdwallet.LoadWallet(from, c.dataDir)
-	//3.2 利用钱包私钥创建身份
-	nonce, err := cli.PendingNonceAt(context.Background(), common.HexToAddress(from))
+	info, err := c.resolveToken(context.Background(), cli, tokenSpec)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	gasPrice, err := cli.SuggestGasPrice(context.Background())
+	value, err := parseDecimal(amount, int(info.Decimals))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-
-	auth := w.HDKeystore.NewTransactOpts()
-	auth.Nonce = big.NewInt(int64(nonce))
-	//注意必须设定auth.Context, 否则报错-> nil Context!!!
-	auth.Context = context.Background()
-
-	auth.Value = big.NewInt(0)     // in wei
-	auth.GasLimit = uint64(300000) // in units
-	//注意必须设置auth.GasPrice, 否则也会报错!!!
-	auth.GasPrice = gasPrice
-
-	//fmt.Println("newtxopts success")
-	//4. 调用transfer
-	_, err = lelecoin.Transfer(auth, common.HexToAddress(toaddr), big.NewInt(value))
-	return err
+	fmt.Printf("from: %s, to: %s, value: %s\n", from, toaddr, formatToken(value, info))
+	//3. 调用transfer
+	return c.transactToken(rpccli, cli, info, from, chainID, overrides, "transfer", common.HexToAddress(toaddr), value)
 }
 
-func (c CmdClient) tokenbalance(from string) (int64, error) {
+func (c CmdClient) tokenbalance(from, tokenSpec string) (*big.Int, error) {
 	//1. 连接以太坊
-	cli, err := ethclient.Dial(c.network)
+	_, cli, err := c.dial()
 	if err != nil {
-		log.Panic("Failed to Dial: ", err)
+		return nil, err
 	}
 	defer cli.Close()
 	//2. 创建Token合约实例, 需要合约地址
-	lelecoin, err := sol.NewLelecoin(common.HexToAddress(LelecoinContractAddr), cli)
+	info, err := c.resolveToken(context.Background(), cli, tokenSpec)
 	if err != nil {
-		log.Panic("Failed to NewLelecoin: ", err)
+		return nil, err
+	}
+	erc20, err := sol.NewERC20(info.Address, cli)
+	if err != nil {
+		return nil, err
 	}
 	//3. 构建CallOpts
 	fromAddr := common.HexToAddress(from)
@@ -154,141 +397,483 @@ func (c CmdClient) tokenbalance(from string) (int64, error) {
 		Context:     nil,
 	}
 
-	value, err := lelecoin.BalanceOf(&opts, fromAddr)
+	value, err := erc20.BalanceOf(&opts, fromAddr)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("%s's token balance is: %s\n", from, formatToken(value, info))
+	return value, nil
+}
+
+//修改账户keystore文件的口令
+func (c CmdClient) changePassword(address string) error {
+	hdks := hdkeystore.NewHDKeyStoreWithoutKey(c.dataDir)
+	oldPass, err := c.password(address)
+	if err != nil {
+		return err
+	}
+	newPass, err := c.newPassword(address)
+	if err != nil {
+		return err
+	}
+	addr := common.HexToAddress(address)
+	if err := hdks.ChangePassword(addr, hdks.JoinPath(addr.Hex()), oldPass, newPass); err != nil {
+		return err
+	}
+	fmt.Println("Password changed for: ", addr.Hex())
+	return nil
+}
+
+//列出keystore目录中的账户, balances为true时同时查询ETH与当前网络所配置代币的余额
+func (c CmdClient) listAccounts(balances bool) error {
+	accounts, err := hdkeystore.NewHDKeyStoreWithoutKey(c.dataDir).Accounts()
+	if err != nil {
+		return err
+	}
+	if !balances {
+		for _, acct := range accounts {
+			fmt.Println(acct.Address.Hex())
+		}
+		return nil
+	}
+	//查询余额
+	_, cli, err := c.dial()
 	if err != nil {
-		log.Panic("Failed to call BalanceOf: ", err)
+		return err
+	}
+	defer cli.Close()
+	infos := c.configuredTokens(context.Background(), cli)
+	tokens := make([]*sol.ERC20, len(infos))
+	for i, info := range infos {
+		if tokens[i], err = sol.NewERC20(info.Address, cli); err != nil {
+			return err
+		}
+	}
+	for _, acct := range accounts {
+		balance, err := cli.BalanceAt(context.Background(), acct.Address, nil)
+		if err != nil {
+			return err
+		}
+		columns := []string{acct.Address.Hex(), formatEther(balance)}
+		for i, erc20 := range tokens {
+			tokenBalance := "-"
+			if value, err := erc20.BalanceOf(&bind.CallOpts{}, acct.Address); err == nil {
+				tokenBalance = formatToken(value, infos[i])
+			}
+			columns = append(columns, tokenBalance)
+		}
+		fmt.Println(strings.Join(columns, "\t"))
 	}
-	fmt.Printf("%s's token balance is: %d\n", from, value.Int64())
-	return value.Int64(), err
+	return nil
 }
 
-func (c CmdClient) tokendetail(who string) error {
+func (c CmdClient) tokendetail(who, tokenSpec, fromBlock, toBlock string, limit int) error {
+	whoAddr, err := parseAddress(who, "-who")
+	if err != nil {
+		return err
+	}
 	//1. connect blockchain network
-	cli, err := ethclient.Dial(c.network)
+	_, cli, err := c.dial()
 	if err != nil {
-		log.Panic("Failed to Dial: ", err)
+		return err
 	}
 	defer cli.Close()
 	//2. 设置过滤条件
-	contractAddr := common.HexToAddress(LelecoinContractAddr)
+	info, err := c.resolveToken(context.Background(), cli, tokenSpec)
+	if err != nil {
+		return err
+	}
+	head, err := cli.BlockNumber(context.Background())
+	if err != nil {
+		return err
+	}
+	start, err := parseBlock(fromBlock, head)
+	if err != nil {
+		return err
+	}
+	end, err := parseBlock(toBlock, head)
+	if err != nil {
+		return err
+	}
+	if start > end {
+		return fmt.Errorf("%w: -fromblock %d is after -toblock %d", ErrInvalidBlock, start, end)
+	}
+	contractAddr := info.Address
 	topicHash := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
-
-	query := ethereum.FilterQuery{
-		BlockHash: nil,
-		FromBlock: nil,
-		ToBlock:   nil,
-		Addresses: []common.Address{contractAddr},
-		Topics:    [][]common.Hash{{topicHash}},
+	//from与to是indexed参数, 由节点按topic过滤, 转出与转入分别查询
+	whoTopic := common.BytesToHash(whoAddr.Bytes())
+	queries := []ethereum.FilterQuery{
+		{Addresses: []common.Address{contractAddr}, Topics: [][]common.Hash{{topicHash}, {whoTopic}}},
+		{Addresses: []common.Address{contractAddr}, Topics: [][]common.Hash{{topicHash}, nil, {whoTopic}}},
 	}
 
-	logs, err := cli.FilterLogs(context.Background(), query)
+	var (
+		records []types.Log
+		next    uint64
+		more    bool
+	)
+	err = scanLogs(context.Background(), cli, queries, start, end, func(_, _ uint64, logs []types.Log) (bool, error) {
+		records = append(records, logs...)
+		if limit <= 0 || len(records) <= limit {
+			return false, nil
+		}
+		//超过limit时在区块边界截断, 下一页从被截断的区块开始, 同一区块的记录不拆分到两页
+		next = records[limit].BlockNumber
+		if next == records[0].BlockNumber {
+			next++
+		}
+		n := sort.Search(len(records), func(i int) bool { return records[i].BlockNumber >= next })
+		more = n < len(records) || next <= end
+		records = records[:n]
+		return true, nil
+	})
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	fmt.Printf("Transfer records of address %s\n", who)
-	for _, vLog := range logs {
+	fmt.Printf("Transfer records of address %s in blocks %d-%d\n", whoAddr.Hex(), start, end)
+	for _, vLog := range records {
 		//再验证一次vLog有效性
 		if contractAddr == vLog.Address && len(vLog.Topics) == 3 && vLog.Topics[0] == topicHash {
-			from := vLog.Topics[1].Bytes()[len(vLog.Topics[1].Bytes()) - 20:]
-			to := vLog.Topics[2].Bytes()[len(vLog.Topics[2].Bytes()) - 20:]
-			val := big.NewInt(0)
-			val.SetBytes(vLog.Data)
-			//注意：以太坊地址在网络中是忽略大小写的
-			//但是golang在字符串比较时需要精准匹配大小写
-			//这里使用strings.ToUpper统一转为大写进行比较
-			if strings.ToUpper(fmt.Sprintf("0x%x", from)) == strings.ToUpper(who) {
-				fmt.Printf("\tfrom: 0x%x\n\tto: 0x%x\n\tvalue: -%d lelecoin\n\tBlockNumber: %d\n", from, to, val.Int64(), vLog.BlockNumber)
+			from := common.BytesToAddress(vLog.Topics[1].Bytes())
+			to := common.BytesToAddress(vLog.Topics[2].Bytes())
+			val := new(big.Int).SetBytes(vLog.Data)
+			if from == whoAddr {
+				fmt.Printf("\tfrom: %s\n\tto: %s\n\tvalue: -%s\n\tBlockNumber: %d\n", from.Hex(), to.Hex(), formatToken(val, info), vLog.BlockNumber)
 				fmt.Println()
 			}
-			if strings.ToUpper(fmt.Sprintf("0x%x", to)) == strings.ToUpper(who) {
-				fmt.Printf("\tfrom: 0x%x\n\tto: 0x%x\n\tvalue: +%d lelecoin\n\tBlockNumber: %d\n", from, to, val.Int64(), vLog.BlockNumber)
+			if to == whoAddr {
+				fmt.Printf("\tfrom: %s\n\tto: %s\n\tvalue: +%s\n\tBlockNumber: %d\n", from.Hex(), to.Hex(), formatToken(val, info), vLog.BlockNumber)
 				fmt.Println()
 			}
 		}
 	}
+	if more {
+		fmt.Printf("more records may follow, continue with -fromblock %d\n", next)
+	}
 	return nil
 }
 
-func (c CmdClient) Run() {
+//解析并执行子命令, 出错时返回错误, 由main决定如何退出
+func (c CmdClient) Run() error {
+	//解析全局参数并加载配置
+	gf := newGlobalFlags()
+	if err := gf.fs.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+	args := gf.fs.Args()
 	//判断参数是否准确
-	if len(os.Args) < 2 {
-		fmt.Println("Unknown Command")
+	if len(args) < 1 {
 		fmt.Println("Run 'wallet help' for usage")
-		os.Exit(-1)
+		return ErrUnknownCommand
+	}
+	if err := c.configure(gf); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
 	}
 
 	//1. 立flag
-	helpCmd := flag.NewFlagSet("help", flag.ExitOnError)
-	cwCmd := flag.NewFlagSet("createwallet", flag.ExitOnError)
-	transferCmd := flag.NewFlagSet("transfer", flag.ExitOnError)
-	getbalanceCmd := flag.NewFlagSet("getbalance", flag.ExitOnError)
-	sendtokenCmd := flag.NewFlagSet("sendtoken", flag.ExitOnError)
-	tokenbalanceCmd := flag.NewFlagSet("tokenbalance", flag.ExitOnError)
-	tokendetailCmd := flag.NewFlagSet("tokendetail", flag.ExitOnError)
+	helpCmd := flag.NewFlagSet("help", flag.ContinueOnError)
+	cwCmd := flag.NewFlagSet("createwallet", flag.ContinueOnError)
+	importmneCmd := flag.NewFlagSet("importmnemonic", flag.ContinueOnError)
+	deriveCmd := flag.NewFlagSet("deriveaccounts", flag.ContinueOnError)
+	newaccountCmd := flag.NewFlagSet("newaccount", flag.ContinueOnError)
+	transferCmd := flag.NewFlagSet("transfer", flag.ContinueOnError)
+	getbalanceCmd := flag.NewFlagSet("getbalance", flag.ContinueOnError)
+	sendtokenCmd := flag.NewFlagSet("sendtoken", flag.ContinueOnError)
+	tokenbalanceCmd := flag.NewFlagSet("tokenbalance", flag.ContinueOnError)
+	tokendetailCmd := flag.NewFlagSet("tokendetail", flag.ContinueOnError)
+	tokeninfoCmd := flag.NewFlagSet("tokeninfo", flag.ContinueOnError)
+	approveCmd := flag.NewFlagSet("approve", flag.ContinueOnError)
+	revokeCmd := flag.NewFlagSet("revoke", flag.ContinueOnError)
+	allowanceCmd := flag.NewFlagSet("allowance", flag.ContinueOnError)
+	transferfromCmd := flag.NewFlagSet("transferfrom", flag.ContinueOnError)
+	deploytokenCmd := flag.NewFlagSet("deploytoken", flag.ContinueOnError)
+	mintCmd := flag.NewFlagSet("mint", flag.ContinueOnError)
+	historyCmd := flag.NewFlagSet("history", flag.ContinueOnError)
+	txstatusCmd := flag.NewFlagSet("txstatus", flag.ContinueOnError)
+	listaccountsCmd := flag.NewFlagSet("listaccounts", flag.ContinueOnError)
+	changepwCmd := flag.NewFlagSet("changepassword", flag.ContinueOnError)
+	importkeyCmd := flag.NewFlagSet("importkey", flag.ContinueOnError)
+	exportkeyCmd := flag.NewFlagSet("exportkey", flag.ContinueOnError)
+	speedupCmd := flag.NewFlagSet("speedup", flag.ContinueOnError)
+	cancelCmd := flag.NewFlagSet("cancel", flag.ContinueOnError)
+	rekeyCmd := flag.NewFlagSet("rekey", flag.ContinueOnError)
 
 	//2. 立flag参数
-	cwCmdPw := cwCmd.String("password", "", "PASSWORD")
+	cwCmdPw := cwCmd.String("password", "", "PASSWORD (deprecated, visible in shell history)")
+	cwCmdWords := cwCmd.Int("words", hdwallet.DefaultMnemonicWords, "12|15|18|21|24")
+	cwCmdLang := cwCmd.String("lang", hdwallet.DefaultMnemonicLanguage, "LANGUAGE")
+	cwCmdPassphrase := cwCmd.Bool("passphrase", false, "prompt for BIP-39 passphrase")
+	cwCmdBackupFile := cwCmd.String("backupfile", "", "FILE")
+	cwCmdKDF := addKDFFlags(cwCmd)
+
+	importmneCmdPath := importmneCmd.String("path", "", "PATH")
+	importmneCmdPassphrase := importmneCmd.Bool("passphrase", false, "prompt for BIP-39 passphrase")
+	importmneCmdKDF := addKDFFlags(importmneCmd)
+
+	deriveCmdCount := deriveCmd.Uint("count", 1, "N")
+	deriveCmdAccount := deriveCmd.Uint("account", 0, "ACCOUNT")
+	deriveCmdStart := deriveCmd.Uint("start", 0, "INDEX")
+	deriveCmdStore := deriveCmd.Bool("store", false, "store derived accounts")
+	deriveCmdPassphrase := deriveCmd.Bool("passphrase", false, "prompt for BIP-39 passphrase")
+	deriveCmdKDF := addKDFFlags(deriveCmd)
 
 	transferCmdFrom := transferCmd.String("from", "", "FROMADDR")
 	transferCmdTo := transferCmd.String("to", "", "TOADDR")
-	transferCmdValue := transferCmd.Int64("value", 0, "VALUE")
+	transferCmdValue := transferCmd.String("value", "0", "AMOUNT")
+	transferCmdChainID := transferCmd.Int64("chainid", 0, "ID")
+	transferCmdFees := addFeeFlags(transferCmd)
+	transferCmdWait := addWaitFlags(transferCmd)
 
 	getbalanceCmdFrom := getbalanceCmd.String("from", "", "FROMADDR")
 
+	sendtokenCmdToken := sendtokenCmd.String("token", defaultToken, "TOKEN")
 	sendtokenCmdFrom := sendtokenCmd.String("from", "", "FROMADDR")
 	sendtokenCmdTo := sendtokenCmd.String("to", "", "TOADDR")
-	sendtokenCmdValue := sendtokenCmd.Int64("value", 0, "VALUE")
+	sendtokenCmdValue := sendtokenCmd.String("value", "0", "AMOUNT")
+	sendtokenCmdChainID := sendtokenCmd.Int64("chainid", 0, "ID")
+	sendtokenCmdFees := addFeeFlags(sendtokenCmd)
+	sendtokenCmdWait := addWaitFlags(sendtokenCmd)
 
+	tokenbalanceCmdToken := tokenbalanceCmd.String("token", defaultToken, "TOKEN")
 	tokenbalanceCmdFrom := tokenbalanceCmd.String("from", "", "FROMADDR")
 
+	detailCmdToken := tokendetailCmd.String("token", defaultToken, "TOKEN")
 	detailCmdWho := tokendetailCmd.String("who", "", "WHO")
+	detailCmdFromBlock := tokendetailCmd.String("fromblock", "0", "BLOCK")
+	detailCmdToBlock := tokendetailCmd.String("toblock", "latest", "BLOCK|latest")
+	detailCmdLimit := tokendetailCmd.Int("limit", 0, "max records, 0 = no limit")
+
+	tokeninfoCmdToken := tokeninfoCmd.String("token", defaultToken, "TOKEN")
+
+	approveCmdToken := approveCmd.String("token", defaultToken, "TOKEN")
+	approveCmdFrom := approveCmd.String("from", "", "OWNER")
+	approveCmdSpender := approveCmd.String("spender", "", "SPENDER")
+	approveCmdValue := approveCmd.String("value", "", "AMOUNT|unlimited")
+	approveCmdChainID := approveCmd.Int64("chainid", 0, "ID")
+	approveCmdFees := addFeeFlags(approveCmd)
+	approveCmdWait := addWaitFlags(approveCmd)
+
+	revokeCmdToken := revokeCmd.String("token", defaultToken, "TOKEN")
+	revokeCmdFrom := revokeCmd.String("from", "", "OWNER")
+	revokeCmdSpender := revokeCmd.String("spender", "", "SPENDER")
+	revokeCmdChainID := revokeCmd.Int64("chainid", 0, "ID")
+	revokeCmdFees := addFeeFlags(revokeCmd)
+	revokeCmdWait := addWaitFlags(revokeCmd)
+
+	allowanceCmdToken := allowanceCmd.String("token", defaultToken, "TOKEN")
+	allowanceCmdOwner := allowanceCmd.String("owner", "", "OWNER")
+	allowanceCmdSpender := allowanceCmd.String("spender", "", "SPENDER")
+
+	transferfromCmdToken := transferfromCmd.String("token", defaultToken, "TOKEN")
+	transferfromCmdSpender := transferfromCmd.String("spender", "", "SPENDER")
+	transferfromCmdFrom := transferfromCmd.String("from", "", "OWNER")
+	transferfromCmdTo := transferfromCmd.String("to", "", "TOADDR")
+	transferfromCmdValue := transferfromCmd.String("value", "0", "AMOUNT")
+	transferfromCmdChainID := transferfromCmd.Int64("chainid", 0, "ID")
+	transferfromCmdFees := addFeeFlags(transferfromCmd)
+	transferfromCmdWait := addWaitFlags(transferfromCmd)
+
+	deploytokenCmdFrom := deploytokenCmd.String("from", "", "FROMADDR")
+	deploytokenCmdName := deploytokenCmd.String("name", "", "TOKEN")
+	deploytokenCmdForce := deploytokenCmd.Bool("force", false, "replace an existing token with the same name")
+	deploytokenCmdChainID := deploytokenCmd.Int64("chainid", 0, "ID")
+	deploytokenCmdFees := addFeeFlags(deploytokenCmd)
+	deploytokenCmdWait := addWaitFlags(deploytokenCmd)
+
+	mintCmdToken := mintCmd.String("token", defaultToken, "TOKEN")
+	mintCmdFrom := mintCmd.String("from", "", "ADMIN")
+	mintCmdTo := mintCmd.String("to", "", "TOADDR")
+	mintCmdValue := mintCmd.String("value", "0", "AMOUNT")
+	mintCmdChainID := mintCmd.Int64("chainid", 0, "ID")
+	mintCmdFees := addFeeFlags(mintCmd)
+	mintCmdWait := addWaitFlags(mintCmd)
+
+	historyCmdAddress := historyCmd.String("address", "", "ADDRESS")
+	historyCmdToken := historyCmd.String("token", "", "TOKEN (default all tokens of the network)")
+	historyCmdOffline := historyCmd.Bool("offline", false, "do not sync from the node")
+	historyCmdLimit := historyCmd.Int("limit", 0, "show the latest N records, 0 = all")
+
+	txstatusCmdHash := txstatusCmd.String("hash", "", "TXHASH")
+
+	listaccountsCmdBalances := listaccountsCmd.Bool("balances", false, "show ETH and lelecoin balances")
+
+	changepwCmdAddress := changepwCmd.String("address", "", "ADDRESS")
+
+	importkeyCmdFile := importkeyCmd.String("file", "", "KEYFILE")
+	importkeyCmdKDF := addKDFFlags(importkeyCmd)
+
+	exportkeyCmdAddress := exportkeyCmd.String("address", "", "ADDRESS")
+	exportkeyCmdFormat := exportkeyCmd.String("format", "json", "hex|json")
+	exportkeyCmdOut := exportkeyCmd.String("out", "", "FILE")
+	exportkeyCmdKDF := addKDFFlags(exportkeyCmd)
+
+	speedupCmdHash := speedupCmd.String("hash", "", "TXHASH")
+	speedupCmdBump := speedupCmd.Uint64("bump", minPriceBump, "fee bump in percent")
+	speedupCmdGasLimit := speedupCmd.Uint64("gaslimit", 0, "GAS (0 = keep the original gas limit)")
+	speedupCmdWait := addWaitFlags(speedupCmd)
+
+	cancelCmdHash := cancelCmd.String("hash", "", "TXHASH")
+	cancelCmdBump := cancelCmd.Uint64("bump", minPriceBump, "fee bump in percent")
+	cancelCmdGasLimit := cancelCmd.Uint64("gaslimit", 0, "GAS (0 = 21000)")
+	cancelCmdWait := addWaitFlags(cancelCmd)
+
+	rekeyCmdAddress := rekeyCmd.String("address", "", "ADDRESS")
+	rekeyCmdAll := rekeyCmd.Bool("all", false, "rekey all accounts and the seed vault")
+	rekeyCmdForce := rekeyCmd.Bool("force", false, "allow re-encrypting with weaker kdf parameters")
+	rekeyCmdKDF := addKDFFlags(rekeyCmd)
+
+	//需要口令的命令共用口令来源参数
+	pwFlags := make(map[string]passwordFlags)
+	for _, fs := range []*flag.FlagSet{cwCmd, importmneCmd, deriveCmd, newaccountCmd, transferCmd, sendtokenCmd,
+		changepwCmd, importkeyCmd, exportkeyCmd, speedupCmd, cancelCmd, rekeyCmd, approveCmd, revokeCmd, transferfromCmd,
+		deploytokenCmd, mintCmd} {
+		pwFlags[fs.Name()] = addPasswordFlags(fs)
+	}
 	//3. 解析命令行参数
-	switch os.Args[1] {
+	switch args[0] {
 	case "help":
-		err := helpCmd.Parse(os.Args[2:])
+		err := helpCmd.Parse(args[1:])
 		if err != nil {
-			fmt.Println("Failed to Parse help flag: ", err)
-			return
+			return err
 		}
 	case "createwallet":
-		err := cwCmd.Parse(os.Args[2:])
+		err := cwCmd.Parse(args[1:])
 		if err != nil {
-			fmt.Println("Failed to Parse createwallet flag: ", err)
-			return
+			return err
+		}
+	case "importmnemonic":
+		err := importmneCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "deriveaccounts":
+		err := deriveCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "newaccount":
+		err := newaccountCmd.Parse(args[1:])
+		if err != nil {
+			return err
 		}
 	case "transfer":
-		err := transferCmd.Parse(os.Args[2:])
+		err := transferCmd.Parse(args[1:])
 		if err != nil {
-			fmt.Println("Failed to Parse transfer flag: ", err)
-			return
+			return err
 		}
 	case "getbalance":
-		err := getbalanceCmd.Parse(os.Args[2:])
+		err := getbalanceCmd.Parse(args[1:])
 		if err != nil {
-			fmt.Println("Failed to Parse getbalance flag: ", err)
-			return
+			return err
 		}
 	case "sendtoken":
-		err := sendtokenCmd.Parse(os.Args[2:])
+		err := sendtokenCmd.Parse(args[1:])
 		if err != nil {
-			fmt.Println("Failed to Parse sendtoken flag: ", err)
-			return
+			return err
 		}
 	case "tokenbalance":
-		err := tokenbalanceCmd.Parse(os.Args[2:])
+		err := tokenbalanceCmd.Parse(args[1:])
 		if err != nil {
-			fmt.Println("Failed to Parse tokenbalance: ", err)
-			return
+			return err
 		}
 	case "tokendetail":
-		err := tokendetailCmd.Parse(os.Args[2:])
+		err := tokendetailCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "tokeninfo":
+		err := tokeninfoCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "approve":
+		err := approveCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "revoke":
+		err := revokeCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "allowance":
+		err := allowanceCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "transferfrom":
+		err := transferfromCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "deploytoken":
+		err := deploytokenCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "mint":
+		err := mintCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "history":
+		err := historyCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "txstatus":
+		err := txstatusCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "listaccounts":
+		err := listaccountsCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "changepassword":
+		err := changepwCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "importkey":
+		err := importkeyCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "exportkey":
+		err := exportkeyCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "speedup":
+		err := speedupCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "cancel":
+		err := cancelCmd.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+	case "rekey":
+		err := rekeyCmd.Parse(args[1:])
 		if err != nil {
-			fmt.Println("Failed to Parse tokendetail: ", err)
+			return err
 		}
 	default:
-		fmt.Println("Unknown Command")
 		fmt.Println("Run 'wallet help' for usage")
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, args[0])
+	}
+
+	//命令行中未指定口令来源时保留调用方设置的来源
+	if pf, ok := pwFlags[args[0]]; ok && pf.given() {
+		provider, err := pf.provider()
+		if err != nil {
+			return err
+		}
+		c.passwords = provider
 	}
 
 	//4. 确认flag参数出现
@@ -297,18 +882,64 @@ func (c CmdClient) Run() {
 	}
 
 	if cwCmd.Parsed() {
-		fmt.Printf("password: %s\n", *cwCmdPw)
-		err := c.createWallet(*cwCmdPw)
+		kdf, err := cwCmdKDF.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse kdf flags: %w", err)
+		}
+		if *cwCmdPw != "" {
+			fmt.Println("warning: -password is visible in shell history, prefer -passwordfile, -passwordenv or -passwordstdin")
+		}
+		err = c.createWallet(*cwCmdPw, *cwCmdWords, *cwCmdLang, *cwCmdPassphrase, *cwCmdBackupFile, kdf)
 		if err != nil {
-			log.Panic("Failed to createWallet: ", err)
+			return fmt.Errorf("failed to createWallet: %w", err)
+		}
+	}
+
+	if importmneCmd.Parsed() {
+		kdf, err := importmneCmdKDF.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse kdf flags: %w", err)
+		}
+		err = c.importMnemonic(*importmneCmdPath, *importmneCmdPassphrase, kdf)
+		if err != nil {
+			return fmt.Errorf("failed to importMnemonic: %w", err)
+		}
+	}
+
+	if deriveCmd.Parsed() {
+		kdf, err := deriveCmdKDF.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse kdf flags: %w", err)
+		}
+		err = c.deriveAccounts(*deriveCmdAccount, *deriveCmdStart, *deriveCmdCount, *deriveCmdStore, *deriveCmdPassphrase, kdf)
+		if err != nil {
+			return fmt.Errorf("failed to deriveAccounts: %w", err)
+		}
+	}
+
+	if newaccountCmd.Parsed() {
+		err := c.newAccount()
+		if err != nil {
+			return fmt.Errorf("failed to newAccount: %w", err)
 		}
 	}
 
 	if transferCmd.Parsed() {
-		fmt.Printf("from: %s, to: %s, value: %d\n", *transferCmdFrom, *transferCmdTo, *transferCmdValue)
-		err := c.transfer(*transferCmdFrom, *transferCmdTo, *transferCmdValue)
+		value, err := parseEtherAmount(*transferCmdValue, "wei")
+		if err != nil {
+			return fmt.Errorf("failed to parse value: %w", err)
+		}
+		fmt.Printf("from: %s, to: %s, value: %s\n", *transferCmdFrom, *transferCmdTo, formatEther(value))
+		overrides, err := transferCmdFees.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse fee flags: %w", err)
+		}
+		tx, err := c.transfer(*transferCmdFrom, *transferCmdTo, value, *transferCmdChainID, overrides)
 		if err != nil {
-			log.Panic("Failed to transfer: ", err)
+			return fmt.Errorf("failed to transfer: %w", err)
+		}
+		if err := c.afterSend(tx, transferCmdWait); err != nil {
+			return fmt.Errorf("failed to wait for transfer: %w", err)
 		}
 		fmt.Println("Success")
 	}
@@ -317,26 +948,212 @@ func (c CmdClient) Run() {
 		fmt.Printf("from: %s\n", *getbalanceCmdFrom)
 		value, err := c.getBalance(*getbalanceCmdFrom)
 		if err != nil {
-			log.Panic("Failed to getBalance: ", err)
+			return fmt.Errorf("failed to getBalance: %w", err)
 		}
-		fmt.Printf("%s's balance is %d\n", *getbalanceCmdFrom, value)
+		fmt.Printf("%s's balance is %s\n", *getbalanceCmdFrom, formatEther(value))
 	}
 
 	if sendtokenCmd.Parsed() {
-		fmt.Printf("from: %s, to: %s, value: %d\n", *sendtokenCmdFrom, *sendtokenCmdTo, *sendtokenCmdValue)
-		err := c.sendToken(*sendtokenCmdFrom, *sendtokenCmdTo, *sendtokenCmdValue)
+		overrides, err := sendtokenCmdFees.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse fee flags: %w", err)
+		}
+		tx, err := c.sendToken(*sendtokenCmdFrom, *sendtokenCmdTo, *sendtokenCmdToken, *sendtokenCmdValue, *sendtokenCmdChainID, overrides)
 		if err != nil {
-			log.Panic("Failed to sendtoken: ", err)
+			return fmt.Errorf("failed to sendtoken: %w", err)
+		}
+		if err := c.afterSend(tx, sendtokenCmdWait); err != nil {
+			return fmt.Errorf("failed to wait for sendtoken: %w", err)
 		}
 		fmt.Println("Success")
 	}
 
 	if tokenbalanceCmd.Parsed() {
-		_, _ = c.tokenbalance(*tokenbalanceCmdFrom)
+		if _, err := c.tokenbalance(*tokenbalanceCmdFrom, *tokenbalanceCmdToken); err != nil {
+			return fmt.Errorf("failed to tokenbalance: %w", err)
+		}
 	}
 
 	if tokendetailCmd.Parsed() {
-		_ = c.tokendetail(*detailCmdWho)
+		if err := c.tokendetail(*detailCmdWho, *detailCmdToken, *detailCmdFromBlock, *detailCmdToBlock, *detailCmdLimit); err != nil {
+			return fmt.Errorf("failed to tokendetail: %w", err)
+		}
+	}
+
+	if tokeninfoCmd.Parsed() {
+		if err := c.tokenInfo(*tokeninfoCmdToken); err != nil {
+			return fmt.Errorf("failed to tokeninfo: %w", err)
+		}
+	}
+
+	if approveCmd.Parsed() {
+		if *approveCmdValue == "" {
+			return errors.New("failed to approve: -value is required, use -value unlimited for an unlimited approval")
+		}
+		overrides, err := approveCmdFees.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse fee flags: %w", err)
+		}
+		tx, err := c.approve(*approveCmdFrom, *approveCmdSpender, *approveCmdToken, *approveCmdValue, *approveCmdChainID, overrides)
+		if err != nil {
+			return fmt.Errorf("failed to approve: %w", err)
+		}
+		if err := c.afterSend(tx, approveCmdWait); err != nil {
+			return fmt.Errorf("failed to wait for approve: %w", err)
+		}
+		fmt.Println("Success")
 	}
-}
 
+	if revokeCmd.Parsed() {
+		overrides, err := revokeCmdFees.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse fee flags: %w", err)
+		}
+		tx, err := c.revoke(*revokeCmdFrom, *revokeCmdSpender, *revokeCmdToken, *revokeCmdChainID, overrides)
+		if err != nil {
+			return fmt.Errorf("failed to revoke: %w", err)
+		}
+		//额度已为0时没有交易
+		if tx != nil {
+			if err := c.afterSend(tx, revokeCmdWait); err != nil {
+				return fmt.Errorf("failed to wait for revoke: %w", err)
+			}
+			fmt.Println("Success")
+		}
+	}
+
+	if allowanceCmd.Parsed() {
+		if _, err := c.allowance(*allowanceCmdOwner, *allowanceCmdSpender, *allowanceCmdToken); err != nil {
+			return fmt.Errorf("failed to allowance: %w", err)
+		}
+	}
+
+	if transferfromCmd.Parsed() {
+		overrides, err := transferfromCmdFees.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse fee flags: %w", err)
+		}
+		tx, err := c.transferFrom(*transferfromCmdSpender, *transferfromCmdFrom, *transferfromCmdTo, *transferfromCmdToken, *transferfromCmdValue, *transferfromCmdChainID, overrides)
+		if err != nil {
+			return fmt.Errorf("failed to transferfrom: %w", err)
+		}
+		if err := c.afterSend(tx, transferfromCmdWait); err != nil {
+			return fmt.Errorf("failed to wait for transferfrom: %w", err)
+		}
+		fmt.Println("Success")
+	}
+
+	if deploytokenCmd.Parsed() {
+		overrides, err := deploytokenCmdFees.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse fee flags: %w", err)
+		}
+		tx, contractAddr, err := c.deployToken(*deploytokenCmdFrom, *deploytokenCmdName, *deploytokenCmdForce, *deploytokenCmdChainID, overrides)
+		if err != nil {
+			return fmt.Errorf("failed to deploytoken: %w", err)
+		}
+		if err := c.afterSend(tx, deploytokenCmdWait); err != nil {
+			return fmt.Errorf("failed to wait for deploytoken, %s not recorded: %w", *deploytokenCmdName, err)
+		}
+		//指定-wait时afterSend在部署成功后才返回nil, 未指定时发送后即记录
+		if err := c.saveToken(*deploytokenCmdName, contractAddr); err != nil {
+			return fmt.Errorf("failed to record %s in %s: %w", *deploytokenCmdName, c.configFile, err)
+		}
+		fmt.Println("Success")
+	}
+
+	if mintCmd.Parsed() {
+		overrides, err := mintCmdFees.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse fee flags: %w", err)
+		}
+		tx, err := c.mint(*mintCmdFrom, *mintCmdTo, *mintCmdToken, *mintCmdValue, *mintCmdChainID, overrides)
+		if err != nil {
+			return fmt.Errorf("failed to mint: %w", err)
+		}
+		if err := c.afterSend(tx, mintCmdWait); err != nil {
+			return fmt.Errorf("failed to wait for mint: %w", err)
+		}
+		fmt.Println("Success")
+	}
+
+	if historyCmd.Parsed() {
+		if err := c.history(*historyCmdAddress, *historyCmdToken, *historyCmdOffline, *historyCmdLimit); err != nil {
+			return fmt.Errorf("failed to history: %w", err)
+		}
+	}
+
+	if txstatusCmd.Parsed() {
+		err := c.txStatus(*txstatusCmdHash)
+		if err != nil {
+			return fmt.Errorf("failed to txStatus: %w", err)
+		}
+	}
+
+	if listaccountsCmd.Parsed() {
+		err := c.listAccounts(*listaccountsCmdBalances)
+		if err != nil {
+			return fmt.Errorf("failed to listAccounts: %w", err)
+		}
+	}
+
+	if changepwCmd.Parsed() {
+		err := c.changePassword(*changepwCmdAddress)
+		if err != nil {
+			return fmt.Errorf("failed to changePassword: %w", err)
+		}
+	}
+
+	if importkeyCmd.Parsed() {
+		kdf, err := importkeyCmdKDF.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse kdf flags: %w", err)
+		}
+		err = c.importKey(*importkeyCmdFile, kdf)
+		if err != nil {
+			return fmt.Errorf("failed to importKey: %w", err)
+		}
+	}
+
+	if exportkeyCmd.Parsed() {
+		kdf, err := exportkeyCmdKDF.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse kdf flags: %w", err)
+		}
+		err = c.exportKey(*exportkeyCmdAddress, *exportkeyCmdFormat, *exportkeyCmdOut, kdf)
+		if err != nil {
+			return fmt.Errorf("failed to exportKey: %w", err)
+		}
+	}
+
+	if speedupCmd.Parsed() {
+		tx, err := c.replaceTx(*speedupCmdHash, false, *speedupCmdBump, *speedupCmdGasLimit)
+		if err != nil {
+			return fmt.Errorf("failed to speedup: %w", err)
+		}
+		if err := c.afterSend(tx, speedupCmdWait); err != nil {
+			return fmt.Errorf("failed to wait for speedup: %w", err)
+		}
+	}
+
+	if cancelCmd.Parsed() {
+		tx, err := c.replaceTx(*cancelCmdHash, true, *cancelCmdBump, *cancelCmdGasLimit)
+		if err != nil {
+			return fmt.Errorf("failed to cancel: %w", err)
+		}
+		if err := c.afterSend(tx, cancelCmdWait); err != nil {
+			return fmt.Errorf("failed to wait for cancel: %w", err)
+		}
+	}
+
+	if rekeyCmd.Parsed() {
+		kdf, err := rekeyCmdKDF.parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse kdf flags: %w", err)
+		}
+		if err := c.rekey(*rekeyCmdAddress, *rekeyCmdAll, *rekeyCmdForce, kdf); err != nil {
+			return fmt.Errorf("failed to rekey: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"os"
+	"strings"
+	"wallet/config"
+	"wallet/sol"
+)
+
+//Lelecoin合约中_admin所在的存储槽, 依次为_balances、_allowed、_totalSupply、_admin
+const lelecoinAdminSlot = 3
+
+var (
+	//调用者不是代币合约的管理员
+	ErrNotAdmin = errors.New("caller is not the token admin")
+	//当前网络已配置同名代币
+	ErrTokenExists = errors.New("token already configured")
+)
+
+//以from身份部署Lelecoin合约, 部署者即管理员, 返回交易与合约地址
+//name为记录到配置中的别名, 已存在时除非force, 否则在发送前拒绝, 避免部署后才发现无法记录
+func (c CmdClient) deployToken(from, name string, force bool, chainID int64, overrides feeOverrides) (*types.Transaction, common.Address, error) {
+	if name == "" {
+		return nil, common.Address{}, errors.New("-name is required")
+	}
+	if old, err := c.tokenAddress(name); err == nil && !force {
+		return nil, common.Address{}, fmt.Errorf("%w: %s is %s on network %s, use -force to replace it", ErrTokenExists, name, old.Hex(), c.networkName)
+	}
+	deployer, err := parseAddress(from, "-from")
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	rpccli, cli, err := c.dial()
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	defer cli.Close()
+	var contractAddr common.Address
+	tx, err := c.transact(rpccli, cli, deployer.Hex(), chainID, overrides, nil, common.FromHex(sol.LelecoinBin), func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		addr, tx, _, err := sol.DeployLelecoin(auth, cli)
+		contractAddr = addr
+		return tx, err
+	})
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	fmt.Println("contract address: ", contractAddr.Hex())
+	return tx, contractAddr, nil
+}
+
+//把代币别名写入当前网络的配置, 配置文件不存在时以内置默认配置为基础创建
+func (c CmdClient) saveToken(name string, addr common.Address) error {
+	cfg, err := config.Load(c.configFile)
+	if os.IsNotExist(err) {
+		//没有配置文件时以当前使用的网络与数据目录创建, 与部署合约的节点一致
+		profile := *c.profile
+		profile.RPC = c.network
+		cfg, err = &config.Config{
+			DefaultNetwork: c.networkName,
+			DataDir:        c.dataDir,
+			Networks:       map[string]*config.Network{c.networkName: &profile},
+		}, nil
+	}
+	if err != nil {
+		return err
+	}
+	network, err := cfg.Network(c.networkName)
+	if err != nil {
+		return err
+	}
+	if network.RPC != "" && network.RPC != c.network {
+		fmt.Printf("warning: token was deployed through %s, network %s in %s uses %s\n", c.network, c.networkName, c.configFile, network.RPC)
+	}
+	if network.Tokens == nil {
+		network.Tokens = make(map[string]common.Address)
+	}
+	//同名代币已在deployToken中按-force确认, 别名不区分大小写
+	for alias, old := range network.Tokens {
+		if strings.EqualFold(alias, name) {
+			fmt.Printf("replacing token %s (was %s)\n", alias, old.Hex())
+			delete(network.Tokens, alias)
+		}
+	}
+	network.Tokens[name] = addr
+	if err := cfg.Save(c.configFile); err != nil {
+		return err
+	}
+	fmt.Printf("recorded token %s on network %s in %s\n", name, c.networkName, c.configFile)
+	return nil
+}
+
+//读取合约存储所需的接口, ethclient.Client与backends.SimulatedBackend已实现
+type storageReader interface {
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
+//Lelecoin合约的管理员
+//_admin是私有变量, 没有查询方法, 直接读取其存储槽
+func lelecoinAdmin(ctx context.Context, backend storageReader, contractAddr common.Address) (common.Address, error) {
+	value, err := backend.StorageAt(ctx, contractAddr, common.BigToHash(big.NewInt(lelecoinAdminSlot)), nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(value), nil
+}
+
+//管理员from为to增发代币, 发送前检查from是否为管理员
+func (c CmdClient) mint(from, toaddr, tokenSpec, amount string, chainID int64, overrides feeOverrides) (*types.Transaction, error) {
+	admin, err := parseAddress(from, "-from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseAddress(toaddr, "-to")
+	if err != nil {
+		return nil, err
+	}
+	rpccli, cli, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+	info, err := c.resolveToken(context.Background(), cli, tokenSpec)
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseDecimal(amount, int(info.Decimals))
+	if err != nil {
+		return nil, err
+	}
+	current, err := lelecoinAdmin(context.Background(), cli, info.Address)
+	if err != nil {
+		return nil, err
+	}
+	if current != admin {
+		return nil, fmt.Errorf("%w: admin of %s is %s", ErrNotAdmin, info.Symbol, current.Hex())
+	}
+	fmt.Printf("admin: %s, to: %s, value: %s\n", admin.Hex(), to.Hex(), formatToken(value, info))
+	lelecoin, err := sol.NewLelecoin(info.Address, cli)
+	if err != nil {
+		return nil, err
+	}
+	lelecoinABI, err := abi.JSON(strings.NewReader(sol.LelecoinABI))
+	if err != nil {
+		return nil, err
+	}
+	data, err := lelecoinABI.Pack("mint", to, value)
+	if err != nil {
+		return nil, err
+	}
+	return c.transact(rpccli, cli, admin.Hex(), chainID, overrides, &info.Address, data, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return lelecoin.Mint(auth, to, value)
+	})
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"testing"
+	"wallet/sol"
+)
+
+func TestDeployAndMintLelecoin(t *testing.T) {
+	adminKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	admin := crypto.PubkeyToAddress(adminKey.PublicKey)
+	other := crypto.PubkeyToAddress(otherKey.PublicKey)
+	funds := new(big.Int).Mul(big.NewInt(100), pow10(18))
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		admin: {Balance: funds},
+		other: {Balance: funds},
+	}, 8000000)
+	defer backend.Close()
+	chainID := big.NewInt(1337)
+	adminAuth, err := bind.NewKeyedTransactorWithChainID(adminKey, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherAuth, err := bind.NewKeyedTransactorWithChainID(otherKey, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//1. 部署, 部署者即管理员
+	addr, _, lelecoin, err := sol.DeployLelecoin(adminAuth, backend)
+	if err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	backend.Commit()
+	symbol, err := lelecoin.Symbol(&bind.CallOpts{})
+	if err != nil || symbol != "Lelecoin" {
+		t.Fatalf("symbol = %q, %v", symbol, err)
+	}
+
+	//2. 从存储槽读取管理员
+	got, err := lelecoinAdmin(context.Background(), backend, addr)
+	if err != nil {
+		t.Fatalf("lelecoinAdmin: %v", err)
+	}
+	if got != admin {
+		t.Fatalf("lelecoinAdmin = %s, want %s", got.Hex(), admin.Hex())
+	}
+
+	//3. 管理员增发
+	if _, err := lelecoin.Mint(adminAuth, other, big.NewInt(500)); err != nil {
+		t.Fatalf("mint: %v", err)
+	}
+	backend.Commit()
+	balance, err := lelecoin.BalanceOf(&bind.CallOpts{}, other)
+	if err != nil || balance.Cmp(big.NewInt(500)) != 0 {
+		t.Fatalf("balance = %v, %v, want 500", balance, err)
+	}
+	supply, err := lelecoin.TotalSupply(&bind.CallOpts{})
+	if err != nil || supply.Cmp(big.NewInt(500)) != 0 {
+		t.Fatalf("total supply = %v, %v, want 500", supply, err)
+	}
+
+	//4. 非管理员增发被合约拒绝
+	if _, err := lelecoin.Mint(otherAuth, other, big.NewInt(1)); err == nil {
+		t.Fatal("mint by non-admin succeeded")
+	}
+}
@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+)
+
+//eth_feeHistory取样的区块数与小费百分位
+const (
+	feeHistoryBlocks     = 10
+	feeHistoryPercentile = 50
+)
+
+//手续费参数与网络不兼容, 如伦敦升级前的网络指定了EIP-1559参数
+var ErrUnsupportedFee = errors.New("unsupported fee flags")
+
+//EstimateGas结果的默认安全系数
+const defaultGasMultiplier = 1.2
+
+//交易手续费参数, GasFeeCap不为nil时构造EIP-1559(type-2)交易, 否则构造legacy交易
+type txFees struct {
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+}
+
+//用户通过命令行指定的手续费, 为nil(0)的字段由节点数据推算
+type feeOverrides struct {
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+	//gas上限, 为0时通过EstimateGas估算
+	GasLimit uint64
+	//估算结果的安全系数, 不小于1, 为0时使用默认值
+	GasMultiplier float64
+}
+
+//eth_feeHistory返回值
+type feeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+//连接节点时探测可达性的超时时间
+const dialTimeout = 10 * time.Second
+
+//同时连接rpc与ethclient, eth_feeHistory需要通过rpc直接调用
+//http连接在首次调用时才建立, 因此先查询一次链ID, 网络错误时返回ErrNodeUnreachable
+func (c CmdClient) dial() (*rpc.Client, *ethclient.Client, error) {
+	rpccli, err := rpc.Dial(c.network)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s: %v", ErrNodeUnreachable, c.network, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	var chainID hexutil.Big
+	if err := rpccli.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		//节点返回的JSON-RPC错误说明节点可达
+		if _, ok := err.(rpc.Error); !ok {
+			rpccli.Close()
+			return nil, nil, fmt.Errorf("%w: %s: %v", ErrNodeUnreachable, c.network, err)
+		}
+	}
+	return rpccli, ethclient.NewClient(rpccli), nil
+}
+
+//计算交易手续费:
+//最新区块无baseFee(伦敦升级前)或用户指定了gasPrice时使用legacy定价, 此时指定maxFee或tip返回错误;
+//否则小费取近期区块的中位数, maxFeePerGas = 2 * 下一区块baseFee + 小费
+func suggestFees(ctx context.Context, rpccli *rpc.Client, ethcli *ethclient.Client, overrides feeOverrides) (*txFees, error) {
+	header, err := ethcli.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if header.BaseFee == nil && (overrides.GasFeeCap != nil || overrides.GasTipCap != nil) {
+		return nil, fmt.Errorf("%w: the network has no baseFee, use -gasprice instead of -maxfee and -tip", ErrUnsupportedFee)
+	}
+	//1. legacy定价
+	if header.BaseFee == nil || overrides.GasPrice != nil {
+		gasPrice := overrides.GasPrice
+		if gasPrice == nil {
+			if gasPrice, err = ethcli.SuggestGasPrice(ctx); err != nil {
+				return nil, err
+			}
+		}
+		return &txFees{GasPrice: gasPrice}, nil
+	}
+	//2. EIP-1559定价
+	baseFee := header.BaseFee
+	tip := overrides.GasTipCap
+	var history feeHistoryResult
+	err = rpccli.CallContext(ctx, &history, "eth_feeHistory", hexutil.Uint(feeHistoryBlocks), "latest", []float64{feeHistoryPercentile})
+	if err == nil {
+		//baseFeePerGas最后一项为下一区块的baseFee
+		if n := len(history.BaseFee); n > 0 && history.BaseFee[n-1] != nil {
+			baseFee = history.BaseFee[n-1].ToInt()
+		}
+		if tip == nil {
+			tip = medianReward(history.Reward)
+		}
+	}
+	//节点不支持eth_feeHistory时退回eth_maxPriorityFeePerGas
+	if tip == nil {
+		if tip, err = ethcli.SuggestGasTipCap(ctx); err != nil {
+			return nil, err
+		}
+	}
+	feeCap := overrides.GasFeeCap
+	if feeCap == nil {
+		feeCap = new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+	}
+	if feeCap.Cmp(tip) < 0 {
+		return nil, fmt.Errorf("maxFeePerGas (%s) < maxPriorityFeePerGas (%s)", feeCap, tip)
+	}
+	return &txFees{GasFeeCap: feeCap, GasTipCap: tip}, nil
+}
+
+//取各区块小费的中位数, 空区块的小费为0不计入, 无数据时返回nil
+func medianReward(rewards [][]*hexutil.Big) *big.Int {
+	tips := make([]*big.Int, 0, len(rewards))
+	for _, r := range rewards {
+		if len(r) > 0 && r[0] != nil && r[0].ToInt().Sign() > 0 {
+			tips = append(tips, r[0].ToInt())
+		}
+	}
+	if len(tips) == 0 {
+		return nil
+	}
+	sort.Slice(tips, func(i, j int) bool {
+		return tips[i].Cmp(tips[j]) < 0
+	})
+	return tips[len(tips)/2]
+}
+
+//按手续费参数构造未签名交易
+func (f txFees) newTx(chainID *big.Int, nonce uint64, to common.Address, value *big.Int, gasLimit uint64, data []byte) *types.Transaction {
+	if f.GasFeeCap == nil {
+		return types.NewTransaction(nonce, to, value, gasLimit, f.GasPrice, data)
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: f.GasTipCap,
+		GasFeeCap: f.GasFeeCap,
+		Gas:       gasLimit,
+		To:        &to,
+		Value:     value,
+		Data:      data,
+	})
+}
+
+//估算gas上限: 用户指定了gas上限时直接使用, 否则将EstimateGas结果乘以安全系数
+func (f txFees) estimateGas(ctx context.Context, ethcli *ethclient.Client, msg ethereum.CallMsg, overrides feeOverrides) (uint64, error) {
+	if overrides.GasLimit != 0 {
+		return overrides.GasLimit, nil
+	}
+	//legacy交易带上gasPrice, 节点会一并检查余额是否足够支付手续费
+	msg.GasPrice = f.GasPrice
+	gas, err := ethcli.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+	multiplier := overrides.GasMultiplier
+	if multiplier == 0 {
+		multiplier = defaultGasMultiplier
+	}
+	return uint64(math.Ceil(float64(gas) * multiplier)), nil
+}
+
+//交易手续费上限: gasLimit * (maxFeePerGas 或 gasPrice)
+func (f txFees) maxCost(gasLimit uint64) *big.Int {
+	price := f.GasPrice
+	if f.GasFeeCap != nil {
+		price = f.GasFeeCap
+	}
+	return new(big.Int).Mul(price, new(big.Int).SetUint64(gasLimit))
+}
+
+//发送前展示gas上限与预估手续费
+func (f txFees) printEstimate(gasLimit uint64) {
+	fmt.Println("fee: ", f)
+	fmt.Printf("gas limit: %d, max fee: %s\n", gasLimit, formatEther(f.maxCost(gasLimit)))
+}
+
+//将手续费参数设置到合约调用身份中
+func (f txFees) apply(auth *bind.TransactOpts) {
+	auth.GasPrice = f.GasPrice
+	auth.GasFeeCap = f.GasFeeCap
+	auth.GasTipCap = f.GasTipCap
+}
+
+func (f txFees) String() string {
+	if f.GasFeeCap == nil {
+		return fmt.Sprintf("legacy, gasPrice: %s", formatGwei(f.GasPrice))
+	}
+	return fmt.Sprintf("EIP-1559, maxFeePerGas: %s, maxPriorityFeePerGas: %s", formatGwei(f.GasFeeCap), formatGwei(f.GasTipCap))
+}
+
+//发送交易命令共用的手续费参数
+type feeFlags struct {
+	gasPrice      *string
+	maxFee        *string
+	tip           *string
+	gasLimit      *uint64
+	gasMultiplier *float64
+}
+
+func addFeeFlags(fs *flag.FlagSet) feeFlags {
+	return feeFlags{
+		gasPrice:      fs.String("gasprice", "", "AMOUNT (default unit gwei)"),
+		maxFee:        fs.String("maxfee", "", "AMOUNT (default unit gwei)"),
+		tip:           fs.String("tip", "", "AMOUNT (default unit gwei)"),
+		gasLimit:      fs.Uint64("gaslimit", 0, "GAS (0 = estimate)"),
+		gasMultiplier: fs.Float64("gasmultiplier", defaultGasMultiplier, "safety multiplier for estimated gas"),
+	}
+}
+
+//解析命令行中的手续费参数, legacy的gasPrice与EIP-1559的maxFee、tip不能同时指定
+func (ff feeFlags) parse() (feeOverrides, error) {
+	var (
+		o   = feeOverrides{GasLimit: *ff.gasLimit, GasMultiplier: *ff.gasMultiplier}
+		err error
+	)
+	if o.GasMultiplier < 1 {
+		return o, fmt.Errorf("-gasmultiplier must be at least 1, got %g", o.GasMultiplier)
+	}
+	if o.GasPrice, err = parseOptionalEtherAmount(*ff.gasPrice, "gwei"); err != nil {
+		return o, err
+	}
+	if o.GasFeeCap, err = parseOptionalEtherAmount(*ff.maxFee, "gwei"); err != nil {
+		return o, err
+	}
+	if o.GasTipCap, err = parseOptionalEtherAmount(*ff.tip, "gwei"); err != nil {
+		return o, err
+	}
+	if o.GasPrice != nil && (o.GasFeeCap != nil || o.GasTipCap != nil) {
+		return o, fmt.Errorf("%w: -gasprice cannot be combined with -maxfee or -tip", ErrUnsupportedFee)
+	}
+	return o, nil
+}
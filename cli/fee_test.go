@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+func TestFeeFlagsParse(t *testing.T) {
+	tests := []struct {
+		args    []string
+		ok      bool
+		wantErr error
+	}{
+		{[]string{}, true, nil},
+		{[]string{"-gasprice", "20"}, true, nil},
+		{[]string{"-maxfee", "30", "-tip", "2"}, true, nil},
+		{[]string{"-gasprice", "20", "-maxfee", "30"}, false, ErrUnsupportedFee},
+		{[]string{"-gasprice", "20", "-tip", "2"}, false, ErrUnsupportedFee},
+		{[]string{"-gasmultiplier", "1"}, true, nil},
+		{[]string{"-gasmultiplier", "1.5"}, true, nil},
+		{[]string{"-gasmultiplier", "0.9"}, false, nil},
+		{[]string{"-gasmultiplier", "0"}, false, nil},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		ff := addFeeFlags(fs)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		_, err := ff.parse()
+		if tt.ok != (err == nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+			t.Errorf("parse(%v) = %v, want ok %v, error %v", tt.args, err, tt.ok, tt.wantErr)
+		}
+	}
+}
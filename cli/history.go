@@ -0,0 +1,365 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+	"wallet/hdkeystore"
+	"wallet/history"
+	"wallet/sol"
+	"wallet/token"
+)
+
+//离线且本地没有该网络的链ID记录
+var ErrNoLocalHistory = errors.New("no local history for network")
+
+//把发送的交易记录到本地历史, 记录失败不影响已发送的交易, 只打印警告
+func (c CmdClient) recordTx(tx *types.Transaction) {
+	if err := c.putTx(tx); err != nil {
+		fmt.Println("warning: failed to record transaction in local history: ", err)
+	}
+}
+
+func (c CmdClient) putTx(tx *types.Transaction) error {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return err
+	}
+	db, err := history.Open(c.dataDir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.PutTx(&history.Tx{
+		Hash:    tx.Hash(),
+		ChainID: tx.ChainId().Uint64(),
+		From:    from,
+		To:      tx.To(),
+		Nonce:   tx.Nonce(),
+		Value:   tx.Value(),
+		Method:  txMethod(tx),
+		Time:    time.Now().Unix(),
+		Status:  history.StatusPending,
+	})
+}
+
+//交易调用的代币合约方法, 普通转账或无法识别时返回空串, 部署合约返回deploy
+func txMethod(tx *types.Transaction) string {
+	if tx.To() == nil {
+		return "deploy"
+	}
+	if len(tx.Data()) < 4 {
+		return ""
+	}
+	lelecoinABI, err := abi.JSON(strings.NewReader(sol.LelecoinABI))
+	if err != nil {
+		return ""
+	}
+	method, err := lelecoinABI.MethodById(tx.Data()[:4])
+	if err != nil {
+		return ""
+	}
+	return method.Name
+}
+
+//本地历史对应的链ID: 在线时以节点为准并记录, 离线时依次使用网络配置与上次记录的值
+func (c CmdClient) historyChainID(db *history.DB, ethcli *ethclient.Client) (uint64, error) {
+	if ethcli != nil {
+		chainID, err := ethcli.ChainID(context.Background())
+		if err != nil {
+			return 0, err
+		}
+		return chainID.Uint64(), db.SetChainID(c.network, chainID.Uint64())
+	}
+	if c.profile.ChainID != 0 {
+		return uint64(c.profile.ChainID), nil
+	}
+	chainID, ok, err := db.ChainID(c.network)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, fmt.Errorf("%w %s, run history once while the node is reachable", ErrNoLocalHistory, c.networkName)
+	}
+	return chainID, nil
+}
+
+//每次同步时重新扫描的最近区块数, 这些区块可能因链重组被替换
+const reorgWindow = 64
+
+//从各账户上次同步的区块之后继续索引代币的Transfer与Approval日志, 并更新交易的状态
+//每段日志写入后即记录同步进度, 中断后从中断处继续; 最近reorgWindow个区块每次重新扫描,
+//以新结果替换本地记录, 被重组移除的事件随之删除
+func syncHistory(ctx context.Context, db *history.DB, ethcli *ethclient.Client, chainID uint64, accounts []common.Address, tokens []*token.Info) error {
+	head, err := ethcli.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	topics := []common.Hash{history.TransferTopic, history.ApprovalTopic}
+	for _, info := range tokens {
+		for _, acct := range accounts {
+			start := uint64(0)
+			synced, ok, err := db.Synced(chainID, info.Address, acct)
+			if err != nil {
+				return err
+			}
+			if ok {
+				start = synced + 1
+				if start > reorgWindow {
+					start -= reorgWindow
+				} else {
+					start = 0
+				}
+			}
+			if start > head {
+				continue
+			}
+			//账户作为from/owner或to/spender, 均为indexed参数
+			acctTopic := common.BytesToHash(acct.Bytes())
+			queries := []ethereum.FilterQuery{
+				{Addresses: []common.Address{info.Address}, Topics: [][]common.Hash{topics, {acctTopic}}},
+				{Addresses: []common.Address{info.Address}, Topics: [][]common.Hash{topics, nil, {acctTopic}}},
+			}
+			err = scanLogs(ctx, ethcli, queries, start, head, func(from, end uint64, logs []types.Log) (bool, error) {
+				var events []*history.Event
+				for _, l := range logs {
+					if l.Removed {
+						continue
+					}
+					if e, ok := history.ParseLog(l); ok {
+						events = append(events, e)
+					}
+				}
+				if err := db.ReplaceEvents(chainID, info.Address, acct, from, end, events); err != nil {
+					return false, err
+				}
+				return false, db.SetSynced(chainID, info.Address, acct, end)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return updateTxStatus(ctx, db, ethcli, chainID, head)
+}
+
+//查询未上链交易与最近reorgWindow个区块中已上链交易的回执
+//没有回执但nonce已被使用的交易视为被替换, 因重组失去回执且nonce未被使用的交易恢复为未上链
+func updateTxStatus(ctx context.Context, db *history.DB, ethcli *ethclient.Client, chainID, head uint64) error {
+	txs, err := db.Txs(chainID, common.Address{})
+	if err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		recent := tx.Block != 0 && tx.Block+reorgWindow > head
+		if tx.Status != history.StatusPending && !recent {
+			continue
+		}
+		old := *tx
+		receipt, err := ethcli.TransactionReceipt(ctx, tx.Hash)
+		if err == ethereum.NotFound {
+			confirmed, err := ethcli.NonceAt(ctx, tx.From, nil)
+			if err != nil {
+				return err
+			}
+			tx.Status, tx.Block = history.StatusPending, 0
+			if confirmed > tx.Nonce {
+				tx.Status = history.StatusReplaced
+			}
+		} else if err != nil {
+			return err
+		} else {
+			tx.Status, tx.Block = history.StatusMined, receipt.BlockNumber.Uint64()
+			if receipt.Status == types.ReceiptStatusFailed {
+				tx.Status = history.StatusFailed
+			}
+		}
+		if tx.Status == old.Status && tx.Block == old.Block {
+			continue
+		}
+		if err := db.PutTx(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//显示本地记录的交易与代币事件
+//节点可达且未指定offline时先增量同步; 节点不可达时提示后只显示本地记录
+//address为空时显示keystore中所有账户, tokenSpec为空时显示当前网络配置的所有代币, limit大于0时每部分只显示最近limit条
+func (c CmdClient) history(address, tokenSpec string, offline bool, limit int) error {
+	var accounts []common.Address
+	if address != "" {
+		addr, err := parseAddress(address, "-address")
+		if err != nil {
+			return err
+		}
+		accounts = append(accounts, addr)
+	} else {
+		accts, err := hdkeystore.NewHDKeyStoreWithoutKey(c.dataDir).Accounts()
+		if err != nil {
+			return err
+		}
+		for _, acct := range accts {
+			accounts = append(accounts, acct.Address)
+		}
+	}
+	//1. 连接节点
+	var ethcli *ethclient.Client
+	if !offline {
+		_, cli, err := c.dial()
+		switch {
+		case errors.Is(err, ErrNodeUnreachable):
+			fmt.Println("warning: node is unreachable, showing local history only: ", err)
+		case err != nil:
+			return err
+		default:
+			ethcli = cli
+			defer cli.Close()
+		}
+	}
+	db, err := history.Open(c.dataDir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	chainID, err := c.historyChainID(db, ethcli)
+	if err != nil {
+		return err
+	}
+	//2. 确定代币, 离线时只能使用缓存的代币信息
+	tokens, err := c.historyTokens(ethcli, chainID, tokenSpec)
+	if err != nil {
+		return err
+	}
+	//3. 增量同步
+	if ethcli != nil {
+		if err := syncHistory(context.Background(), db, ethcli, chainID, accounts, tokens); err != nil {
+			return err
+		}
+	}
+	//4. 显示
+	for _, acct := range accounts {
+		fmt.Printf("History of address %s\n", acct.Hex())
+		txs, err := db.Txs(chainID, acct)
+		if err != nil {
+			return err
+		}
+		if limit > 0 && len(txs) > limit {
+			txs = txs[len(txs)-limit:]
+		}
+		fmt.Println("sent transactions:")
+		for _, tx := range txs {
+			fmt.Println("\t" + formatHistoryTx(tx))
+		}
+		for _, info := range tokens {
+			events, err := db.Events(chainID, info.Address, acct)
+			if err != nil {
+				return err
+			}
+			if limit > 0 && len(events) > limit {
+				events = events[len(events)-limit:]
+			}
+			fmt.Printf("%s events:\n", info.Symbol)
+			for _, e := range events {
+				fmt.Println("\t" + formatHistoryEvent(e, acct, info))
+			}
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+//历史中显示的代币: 指定-token时只显示该代币, 否则显示网络配置的所有代币
+//离线时代币信息取自注册表缓存, 跳过没有缓存的代币
+func (c CmdClient) historyTokens(ethcli *ethclient.Client, chainID uint64, tokenSpec string) ([]*token.Info, error) {
+	ctx := context.Background()
+	if ethcli != nil {
+		if tokenSpec != "" {
+			info, err := c.resolveToken(ctx, ethcli, tokenSpec)
+			if err != nil {
+				return nil, err
+			}
+			return []*token.Info{info}, nil
+		}
+		return c.configuredTokens(ctx, ethcli), nil
+	}
+	var addrs []common.Address
+	switch {
+	case common.IsHexAddress(tokenSpec):
+		addrs = append(addrs, common.HexToAddress(tokenSpec))
+	case tokenSpec != "":
+		addr, err := c.tokenAddress(tokenSpec)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	default:
+		aliases := make([]string, 0, len(c.profile.Tokens))
+		for alias := range c.profile.Tokens {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+		for _, alias := range aliases {
+			addrs = append(addrs, c.profile.Tokens[alias])
+		}
+	}
+	registry := token.NewRegistry(c.dataDir)
+	infos := make([]*token.Info, 0, len(addrs))
+	for _, addr := range addrs {
+		info, ok, err := registry.Cached(new(big.Int).SetUint64(chainID), addr)
+		if err != nil {
+			return nil, err
+		}
+		//未缓存的代币从未同步过, 本地没有它的事件
+		if !ok {
+			fmt.Printf("warning: skipped token %s: not in the local token cache\n", addr.Hex())
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func formatHistoryTx(tx *history.Tx) string {
+	method := tx.Method
+	if method == "" {
+		method = "ETH transfer"
+	}
+	to := "-"
+	if tx.To != nil {
+		to = tx.To.Hex()
+	}
+	status := tx.Status
+	if tx.Block != 0 {
+		status = fmt.Sprintf("%s in block %d", tx.Status, tx.Block)
+	}
+	return fmt.Sprintf("%s nonce: %d %s to: %s value: %s hash: %s %s",
+		time.Unix(tx.Time, 0).Format("2006-01-02 15:04:05"), tx.Nonce, method, to, formatEther(tx.Value), tx.Hash.Hex(), status)
+}
+
+//以acct的视角显示事件: 转出为负, 转入为正, 授权显示owner与spender
+func formatHistoryEvent(e *history.Event, acct common.Address, info *token.Info) string {
+	if e.Name == "Approval" {
+		return fmt.Sprintf("block %d Approval owner: %s spender: %s allowance: %s tx: %s",
+			e.Block, e.From.Hex(), e.To.Hex(), formatAllowance(e.Value, info), e.TxHash.Hex())
+	}
+	sign := "+"
+	if e.From == acct {
+		sign = "-"
+	}
+	if e.From == acct && e.To == acct {
+		sign = ""
+	}
+	return fmt.Sprintf("block %d Transfer from: %s to: %s value: %s%s tx: %s",
+		e.Block, e.From.Hex(), e.To.Hex(), sign, formatToken(e.Value, info), e.TxHash.Hex())
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"os"
+	"wallet/hdkeystore"
+)
+
+//命令行默认使用以太坊standard强度的密钥派生参数
+const defaultKDF = "standard"
+
+//目标密钥派生参数弱于文件现有参数
+var ErrWeakerKDF = errors.New("weaker kdf")
+
+//写keystore文件的命令共用的密钥派生参数
+type kdfFlags struct {
+	kdf     *string
+	scryptN *int
+	scryptP *int
+	pbkdf2C *int
+}
+
+//为命令注册-kdf、-scryptn、-scryptp与-pbkdf2c参数
+func addKDFFlags(fs *flag.FlagSet) kdfFlags {
+	return kdfFlags{
+		kdf:     fs.String("kdf", defaultKDF, "light|standard|pbkdf2"),
+		scryptN: fs.Int("scryptn", 0, "custom scrypt N (power of 2)"),
+		scryptP: fs.Int("scryptp", 0, "custom scrypt P"),
+		pbkdf2C: fs.Int("pbkdf2c", 0, "custom pbkdf2 iteration count"),
+	}
+}
+
+//解析命令行中的密钥派生参数, 自定义参数覆盖预设值
+func (kf kdfFlags) parse() (hdkeystore.KDF, error) {
+	kdf, err := hdkeystore.KDFByName(*kf.kdf)
+	if err != nil {
+		return kdf, err
+	}
+	if kdf.Name == hdkeystore.KDFScrypt {
+		if *kf.scryptN != 0 {
+			kdf.N = *kf.scryptN
+		}
+		if *kf.scryptP != 0 {
+			kdf.P = *kf.scryptP
+		}
+	} else if *kf.pbkdf2C != 0 {
+		kdf.C = *kf.pbkdf2C
+	}
+	return kdf, kdf.Validate()
+}
+
+//以新的密钥派生参数重新加密keystore文件, 口令不变
+//all为true时处理目录中所有账户及种子文件, 已是目标参数的文件跳过
+//目标参数弱于文件现有参数时拒绝执行, force为true时只警告; 检查在修改任何文件之前完成
+func (c CmdClient) rekey(address string, all, force bool, kdf hdkeystore.KDF) error {
+	hdks := hdkeystore.NewHDKeyStoreWithoutKey(c.dataDir)
+	var accounts []hdkeystore.Account
+	if all {
+		var err error
+		if accounts, err = hdks.Accounts(); err != nil {
+			return err
+		}
+	} else {
+		if address == "" {
+			return errors.New("either -address or -all is required")
+		}
+		addr := common.HexToAddress(address)
+		accounts = []hdkeystore.Account{{Address: addr, File: hdks.JoinPath(addr.Hex())}}
+	}
+	//1. 读取现有参数并检查强度
+	currents := make([]hdkeystore.KDF, len(accounts))
+	for i, acct := range accounts {
+		current, err := hdkeystore.ReadKDF(acct.File)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", hdkeystore.ErrAccountNotFound, acct.Address.Hex())
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkWeakerKDF(acct.Address.Hex(), current, kdf, force); err != nil {
+			return err
+		}
+		currents[i] = current
+	}
+	rekeySeed := all && hdks.HasSeed()
+	var seedKDF hdkeystore.KDF
+	if rekeySeed {
+		var err error
+		if seedKDF, err = hdks.SeedKDF(); err != nil {
+			return err
+		}
+		if err := checkWeakerKDF("seed vault", seedKDF, kdf, force); err != nil {
+			return err
+		}
+	}
+	//2. 重新加密
+	for i, acct := range accounts {
+		if currents[i] == kdf {
+			fmt.Printf("%s already uses %s, skipped\n", acct.Address.Hex(), kdf)
+			continue
+		}
+		pass, err := c.password(acct.Address.Hex())
+		if err != nil {
+			return err
+		}
+		if err := hdks.Rekey(acct.Address, acct.File, pass, pass, kdf); err != nil {
+			return err
+		}
+		fmt.Printf("%s: %s -> %s\n", acct.Address.Hex(), currents[i], kdf)
+	}
+	if !rekeySeed {
+		return nil
+	}
+	if seedKDF == kdf {
+		fmt.Printf("seed vault already uses %s, skipped\n", kdf)
+		return nil
+	}
+	pass, err := c.password("seed vault")
+	if err != nil {
+		return err
+	}
+	if err := hdks.RekeySeed(pass, pass, kdf); err != nil {
+		return err
+	}
+	fmt.Printf("seed vault: %s -> %s\n", seedKDF, kdf)
+	return nil
+}
+
+//目标参数弱于现有参数时, 未指定force返回错误, 否则打印警告
+func checkWeakerKDF(who string, current, target hdkeystore.KDF, force bool) error {
+	if !target.Weaker(current) {
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("%w: %s uses %s, refusing to downgrade to %s without -force", ErrWeakerKDF, who, current, target)
+	}
+	fmt.Printf("warning: downgrading %s from %s to %s\n", who, current, target)
+	return nil
+}
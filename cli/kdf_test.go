@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"wallet/hdkeystore"
+)
+
+//keystore目录中没有该账户时返回ErrAccountNotFound, 而不是文件系统错误
+func TestRekeyMissingAccount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := NewCmdClient("", dir)
+	err = c.rekey("0x9858EfFD232B4033E47d90003D41EC34EcaEda94", false, false, hdkeystore.LightKDF)
+	if !errors.Is(err, hdkeystore.ErrAccountNotFound) {
+		t.Fatalf("rekey = %v, want ErrAccountNotFound", err)
+	}
+}
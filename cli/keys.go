@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/howeyc/gopass"
+	"io/ioutil"
+	"wallet/hdkeystore"
+	"wallet/hdwallet"
+)
+
+//导入私钥: keyFile为空时从终端读取16进制私钥, 否则读取V3 keystore文件并输入其口令
+//导入后以kdf参数与新口令储存
+func (c CmdClient) importKey(keyFile string, kdf hdkeystore.KDF) error {
+	var (
+		w   *hdwallet.HDWallet
+		err error
+	)
+	if keyFile == "" {
+		fmt.Println("Please input private key (hex): ")
+		hexkey, err := gopass.GetPasswd()
+		if err != nil {
+			return err
+		}
+		w, err = hdwallet.NewHDWalletFromPrivateKey(string(hexkey), c.dataDir)
+		if err != nil {
+			return err
+		}
+	} else {
+		keyjson, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return err
+		}
+		auth, err := c.password(keyFile)
+		if err != nil {
+			return err
+		}
+		w, err = hdwallet.NewHDWalletFromKeyJSON(keyjson, auth, c.dataDir)
+		if err != nil {
+			return err
+		}
+	}
+	pass, err := c.newPassword(w.Address.Hex())
+	if err != nil {
+		return err
+	}
+	if err := w.HDKeystore.SetKDF(kdf); err != nil {
+		return err
+	}
+	if err := w.StoreKey(pass); err != nil {
+		return err
+	}
+	fmt.Println("Imported account: ", w.Address.Hex())
+	return nil
+}
+
+//导出私钥: format为hex时导出16进制私钥, 为json时以kdf参数导出标准V3 keystore
+//outFile为空时输出到终端, 否则写入权限为0600的新文件
+func (c CmdClient) exportKey(address, format, outFile string, kdf hdkeystore.KDF) error {
+	hdks := hdkeystore.NewHDKeyStoreWithoutKey(c.dataDir)
+	if err := hdks.SetKDF(kdf); err != nil {
+		return err
+	}
+	addr := common.HexToAddress(address)
+	auth, err := c.password(addr.Hex())
+	if err != nil {
+		return err
+	}
+	var content []byte
+	switch format {
+	case "hex":
+		hexkey, err := hdks.ExportPrivateKey(addr, hdks.JoinPath(addr.Hex()), auth)
+		if err != nil {
+			return err
+		}
+		content = []byte(hexkey + "\n")
+	case "json":
+		newAuth, err := c.newPassword("exported keystore")
+		if err != nil {
+			return err
+		}
+		if content, err = hdks.ExportKeyJSON(addr, hdks.JoinPath(addr.Hex()), auth, newAuth); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown export format %q, want hex or json", format)
+	}
+	if outFile == "" {
+		fmt.Println(string(content))
+		return nil
+	}
+	if err := writeSecretFile(outFile, content); err != nil {
+		return err
+	}
+	fmt.Println("Exported to: ", outFile)
+	return nil
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//分段查询日志的初始与最大区块跨度
+const (
+	defaultLogWindow = 2000
+	maxLogWindow     = 100000
+)
+
+//单次日志查询的超时, 超时视为结果过多并缩小跨度
+const logQueryTimeout = 30 * time.Second
+
+//区块参数不合法
+var ErrInvalidBlock = errors.New("invalid block")
+
+//解析区块参数, latest表示当前最新区块
+func parseBlock(s string, head uint64) (uint64, error) {
+	if strings.EqualFold(strings.TrimSpace(s), "latest") {
+		return head, nil
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidBlock, s)
+	}
+	return n, nil
+}
+
+//节点与服务商拒绝日志查询时的错误信息, 表示结果过多、区块范围过大或查询超时
+var tooManyResultsMessages = []string{
+	//Infura等: query returned more than 10000 results
+	"query returned more than",
+	//Alchemy: Log response size exceeded
+	"response size exceeded",
+	"response size is larger than",
+	//Cloudflare、Ankr等: block range is too wide / block range too large
+	"block range is too",
+	"block range too",
+	//BSC、Polygon节点: exceed maximum block range: 5000
+	"exceed maximum block range",
+	"exceeds maximum block range",
+	"max block range",
+	//QuickNode: eth_getLogs is limited to a 10,000 range
+	"eth_getlogs is limited to",
+	"too many results",
+	"too many logs",
+	"log limit exceeded",
+	//geth与Erigon: query timeout exceeded
+	"query timeout",
+}
+
+//节点拒绝查询是否因为结果过多或区块范围过大, 各节点与服务商的错误信息不同, 按已知措辞匹配
+//其他错误(如连接失败)不缩小范围重试, 直接返回
+func isTooManyResults(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range tooManyResultsMessages {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+//在[from, to]区块范围内分段执行queries, 各段的日志合并去重后按区块与日志序号排序交给fn
+//节点报告结果过多时跨度减半重试, 查询成功后跨度加倍; fn返回true时停止查询
+func scanLogs(ctx context.Context, ethcli *ethclient.Client, queries []ethereum.FilterQuery, from, to uint64, fn func(start, end uint64, logs []types.Log) (bool, error)) error {
+	window := uint64(defaultLogWindow)
+	for start := from; start <= to; {
+		end := to
+		if to-start >= window {
+			end = start + window - 1
+		}
+		logs, err := filterRange(ctx, ethcli, queries, start, end)
+		if err != nil {
+			if window > 1 && isTooManyResults(err) {
+				window /= 2
+				continue
+			}
+			return fmt.Errorf("failed to filter logs in blocks %d-%d: %w", start, end, err)
+		}
+		stop, err := fn(start, end, logs)
+		if err != nil || stop {
+			return err
+		}
+		if end == to {
+			break
+		}
+		start = end + 1
+		if window < maxLogWindow {
+			window *= 2
+		}
+	}
+	return nil
+}
+
+//在一段区块内执行所有查询, 同一条日志可能匹配多个查询, 只保留一次
+func filterRange(ctx context.Context, ethcli *ethclient.Client, queries []ethereum.FilterQuery, start, end uint64) ([]types.Log, error) {
+	type logID struct {
+		block uint64
+		index uint
+	}
+	seen := make(map[logID]bool)
+	var logs []types.Log
+	for _, q := range queries {
+		q.FromBlock = new(big.Int).SetUint64(start)
+		q.ToBlock = new(big.Int).SetUint64(end)
+		qctx, cancel := context.WithTimeout(ctx, logQueryTimeout)
+		result, err := ethcli.FilterLogs(qctx, q)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range result {
+			id := logID{l.BlockNumber, l.Index}
+			if l.Removed || seen[id] {
+				continue
+			}
+			seen[id] = true
+			logs = append(logs, l)
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+	return logs, nil
+}
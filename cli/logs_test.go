@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsTooManyResults(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("query returned more than 10000 results"), true},
+		{errors.New("Log response size exceeded. You can make eth_getLogs requests with up to a 2K block range"), true},
+		{errors.New("block range is too wide"), true},
+		{errors.New("exceed maximum block range: 5000"), true},
+		{errors.New("eth_getLogs is limited to a 10,000 range"), true},
+		{errors.New("query timeout exceeded"), true},
+		{fmt.Errorf("failed: %w", context.DeadlineExceeded), true},
+		{errors.New("invalid block range params"), false},
+		{errors.New("block number out of range"), false},
+		{errors.New("dial tcp 127.0.0.1:8545: connect: connection refused"), false},
+		{errors.New("429 Too Many Requests"), false},
+		{errors.New("invalid argument 0: hex string without 0x prefix"), false},
+	}
+	for _, tt := range tests {
+		if got := isTooManyResults(tt.err); got != tt.want {
+			t.Errorf("isTooManyResults(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
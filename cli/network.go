@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"os"
+	"strings"
+	"wallet/config"
+)
+
+//当前网络未配置该代币的合约地址
+var ErrTokenNotConfigured = errors.New("token not configured")
+
+//所有命令之前的全局参数
+type globalFlags struct {
+	fs      *flag.FlagSet
+	config  *string
+	network *string
+	rpc     *string
+	dataDir *string
+}
+
+func newGlobalFlags() globalFlags {
+	fs := flag.NewFlagSet("wallet", flag.ContinueOnError)
+	return globalFlags{
+		fs:      fs,
+		config:  fs.String("config", config.FileName, "FILE"),
+		network: fs.String("network", "", "NAME"),
+		rpc:     fs.String("rpc", "", "URL (overrides the network's rpc)"),
+		dataDir: fs.String("datadir", "", "DIR (overrides the config's datadir)"),
+	}
+}
+
+//是否在命令行中指定了name参数
+func (gf globalFlags) isSet(name string) bool {
+	set := false
+	gf.fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+//读取配置文件并选定网络, 优先级: 命令行参数 > 配置文件 > NewCmdClient传入的值
+//未显式指定-config且默认配置文件不存在时使用内置默认配置, 其中默认网络的节点与数据目录取NewCmdClient传入的值
+func (c *CmdClient) configure(gf globalFlags) error {
+	cfg, err := config.Load(*gf.config)
+	if os.IsNotExist(err) && !gf.isSet("config") {
+		cfg, err = config.Default(), nil
+		cfg.Networks[config.DefaultNetwork] = c.profile
+		cfg.DataDir = c.dataDir
+	}
+	if err != nil {
+		return err
+	}
+	name := *gf.network
+	if name == "" {
+		name = cfg.DefaultNetwork
+	}
+	if name == "" {
+		name = config.DefaultNetwork
+	}
+	profile, err := cfg.Network(name)
+	if err != nil {
+		return err
+	}
+	c.configFile, c.networkName, c.profile = *gf.config, name, profile
+	if profile.RPC != "" {
+		c.network = profile.RPC
+	}
+	if cfg.DataDir != "" {
+		c.dataDir = cfg.DataDir
+	}
+	if *gf.rpc != "" {
+		c.network = *gf.rpc
+	}
+	if *gf.dataDir != "" {
+		c.dataDir = *gf.dataDir
+	}
+	return nil
+}
+
+//当前网络中名为name的代币合约地址, 名称不区分大小写
+func (c CmdClient) tokenAddress(name string) (common.Address, error) {
+	for token, addr := range c.profile.Tokens {
+		if strings.EqualFold(token, name) {
+			return addr, nil
+		}
+	}
+	return common.Address{}, fmt.Errorf("%w: %s on network %s", ErrTokenNotConfigured, name, c.networkName)
+}
+
+//命令行未指定链ID时使用网络配置中的链ID
+func (c CmdClient) chainID(flagValue int64) int64 {
+	if flagValue != 0 {
+		return flagValue
+	}
+	return c.profile.ChainID
+}
+
+//区块浏览器中的交易链接, 未配置浏览器时返回空串
+func (c CmdClient) txURL(hash common.Hash) string {
+	if c.profile.Explorer == "" {
+		return ""
+	}
+	return strings.TrimRight(c.profile.Explorer, "/") + "/tx/" + hash.Hex()
+}
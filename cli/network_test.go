@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"wallet/config"
+)
+
+//没有配置文件时使用NewCmdClient传入的节点与数据目录, 命令行参数优先
+func TestConfigureWithoutConfigFile(t *testing.T) {
+	c := NewCmdClient("http://127.0.0.1:9545", "/tmp/wallet-data")
+	gf := newGlobalFlags()
+	if err := c.configure(gf); err != nil {
+		t.Fatal(err)
+	}
+	if c.network != "http://127.0.0.1:9545" || c.dataDir != "/tmp/wallet-data" || c.networkName != config.DefaultNetwork {
+		t.Fatalf("configure = %s %s %s, want the NewCmdClient values", c.network, c.dataDir, c.networkName)
+	}
+	if len(c.profile.Tokens) == 0 {
+		t.Fatal("default tokens missing")
+	}
+
+	c = NewCmdClient("http://127.0.0.1:9545", "/tmp/wallet-data")
+	gf = newGlobalFlags()
+	if err := gf.fs.Parse([]string{"-rpc", "http://127.0.0.1:8546", "-datadir", "/tmp/other"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.configure(gf); err != nil {
+		t.Fatal(err)
+	}
+	if c.network != "http://127.0.0.1:8546" || c.dataDir != "/tmp/other" {
+		t.Fatalf("configure = %s %s, want the command line values", c.network, c.dataDir)
+	}
+}
+
+//配置文件未指定defaultNetwork时使用默认网络名
+func TestConfigureWithoutDefaultNetwork(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, config.FileName)
+	content := `{"networks": {"` + config.DefaultNetwork + `": {"rpc": "http://127.0.0.1:7545"}}}`
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := NewCmdClient("http://127.0.0.1:9545", dir)
+	gf := newGlobalFlags()
+	if err := gf.fs.Parse([]string{"-config", file}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.configure(gf); err != nil {
+		t.Fatal(err)
+	}
+	if c.networkName != config.DefaultNetwork || c.network != "http://127.0.0.1:7545" {
+		t.Fatalf("configure = %s %s, want network %s", c.networkName, c.network, config.DefaultNetwork)
+	}
+}
+
+//没有配置文件时记录代币, 新配置文件使用部署时的节点与数据目录
+func TestSaveTokenWithoutConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := NewCmdClient("http://127.0.0.1:9545", dir)
+	gf := newGlobalFlags()
+	if err := gf.fs.Parse([]string{"-rpc", "http://127.0.0.1:8546"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.configure(gf); err != nil {
+		t.Fatal(err)
+	}
+	c.configFile = filepath.Join(dir, config.FileName)
+	addr := common.HexToAddress("0x967b24fe559fCaAA57d5F3Fa033517fdaA005bD6")
+	if err := c.saveToken("mytoken", addr); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(c.configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network, err := cfg.Network(config.DefaultNetwork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network.RPC != "http://127.0.0.1:8546" || cfg.DataDir != dir || network.Tokens["mytoken"] != addr {
+		t.Fatalf("saved config = %+v, network = %+v", cfg, network)
+	}
+}
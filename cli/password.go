@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"wallet/password"
+)
+
+//读取口令的命令共用的口令来源参数, 均未指定时在终端提示输入
+type passwordFlags struct {
+	file  *string
+	env   *string
+	stdin *bool
+}
+
+//为命令注册-passwordfile、-passwordenv与-passwordstdin参数
+func addPasswordFlags(fs *flag.FlagSet) passwordFlags {
+	return passwordFlags{
+		file:  fs.String("passwordfile", "", "read passwords from FILE, one per line"),
+		env:   fs.String("passwordenv", "", "read password from environment variable NAME"),
+		stdin: fs.Bool("passwordstdin", false, "read passwords from stdin, one per line"),
+	}
+}
+
+//是否指定了任一口令来源
+func (pf passwordFlags) given() bool {
+	return *pf.file != "" || *pf.env != "" || *pf.stdin
+}
+
+//根据参数构建口令来源, 最多只能指定一种
+func (pf passwordFlags) provider() (password.Provider, error) {
+	var providers []password.Provider
+	if *pf.file != "" {
+		file, err := password.NewFile(*pf.file)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, file)
+	}
+	if *pf.env != "" {
+		providers = append(providers, password.Env(*pf.env))
+	}
+	if *pf.stdin {
+		providers = append(providers, password.Stdin())
+	}
+	switch len(providers) {
+	case 0:
+		return password.TTY{}, nil
+	case 1:
+		return providers[0], nil
+	}
+	return nil, errors.New("only one of -passwordfile, -passwordenv and -passwordstdin may be given")
+}
+
+//设置口令来源, 供以程序方式调用命令时使用回调提供口令
+func (c *CmdClient) SetPasswordProvider(p password.Provider) {
+	c.passwords = p
+}
+
+//读取已有账户或种子文件的口令
+func (c CmdClient) password(who string) (string, error) {
+	return c.passwords.Password(who)
+}
+
+//读取新口令, 终端输入时要求重复确认
+func (c CmdClient) newPassword(who string) (string, error) {
+	return password.New(c.passwords, who)
+}
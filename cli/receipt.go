@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"math/big"
+	"time"
+)
+
+//轮询交易回执的间隔
+const receiptPollInterval = 2 * time.Second
+
+//默认等待超时
+const defaultWaitTimeout = 5 * time.Minute
+
+//等待回执超时
+var ErrWaitTimeout = errors.New("timed out waiting for transaction")
+
+//交易上链但执行失败
+var ErrTxFailed = errors.New("transaction failed")
+
+//发送交易命令共用的等待参数
+type waitFlags struct {
+	confirmations *uint64
+	timeout       *time.Duration
+}
+
+func addWaitFlags(fs *flag.FlagSet) waitFlags {
+	return waitFlags{
+		confirmations: fs.Uint64("wait", 0, "wait for N confirmations (0 = do not wait)"),
+		timeout:       fs.Duration("timeout", defaultWaitTimeout, "timeout for -wait"),
+	}
+}
+
+//交易状态
+type txStatus struct {
+	Status        string
+	BlockNumber   *big.Int
+	Confirmations uint64
+	GasUsed       uint64
+	GasPrice      *big.Int
+	Fee           *big.Int
+	RevertReason  string
+}
+
+func (s txStatus) String() string {
+	if s.BlockNumber == nil {
+		return "status: " + s.Status
+	}
+	str := fmt.Sprintf("status: %s\nblock: %s\nconfirmations: %d\ngas used: %d\neffective gas price: %s\nfee: %s",
+		s.Status, s.BlockNumber, s.Confirmations, s.GasUsed, formatGwei(s.GasPrice), formatEther(s.Fee))
+	//revert原因由重放得到, 未包含同一区块中排在前面的交易, 只能作为参考
+	if s.RevertReason != "" {
+		str += fmt.Sprintf("\nrevert reason (approximate, replayed on the state of block %s): %s",
+			new(big.Int).Sub(s.BlockNumber, big.NewInt(1)), s.RevertReason)
+	}
+	return str
+}
+
+//发送后记录到本地历史并打印交易哈希, confirmations大于0时等待确认
+func (c CmdClient) afterSend(tx *types.Transaction, wf waitFlags) error {
+	c.recordTx(tx)
+	fmt.Println("tx hash: ", tx.Hash().Hex())
+	if url := c.txURL(tx.Hash()); url != "" {
+		fmt.Println("explorer: ", url)
+	}
+	if *wf.confirmations == 0 {
+		return nil
+	}
+	_, ethcli, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer ethcli.Close()
+	status, err := waitForTx(ethcli, tx.Hash(), *wf.confirmations, *wf.timeout)
+	if err != nil {
+		return err
+	}
+	fmt.Println(status)
+	if status.Status == "failed" {
+		return ErrTxFailed
+	}
+	return nil
+}
+
+//轮询交易回执直到达到confirmations个确认或超时
+func waitForTx(ethcli *ethclient.Client, hash common.Hash, confirmations uint64, timeout time.Duration) (*txStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+	for {
+		status, err := queryTxStatus(ctx, ethcli, hash)
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		if err == nil && status.BlockNumber != nil && status.Confirmations >= confirmations {
+			return status, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w %s", ErrWaitTimeout, hash.Hex())
+		case <-ticker.C:
+		}
+	}
+}
+
+//查询交易状态: pending/mined/failed, 上链的交易附带gas用量与实际手续费
+func queryTxStatus(ctx context.Context, ethcli *ethclient.Client, hash common.Hash) (*txStatus, error) {
+	//1. 查询交易
+	tx, isPending, err := ethcli.TransactionByHash(ctx, hash)
+	if err == ethereum.NotFound {
+		return &txStatus{Status: "not found"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isPending {
+		return &txStatus{Status: "pending"}, nil
+	}
+	//2. 查询回执
+	receipt, err := ethcli.TransactionReceipt(ctx, hash)
+	if err == ethereum.NotFound {
+		return &txStatus{Status: "pending"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	head, err := ethcli.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	header, err := ethcli.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	//3. 实际gas价格: 伦敦升级后为baseFee + 实际小费, 之前为gasPrice
+	gasPrice := tx.GasPrice()
+	if header.BaseFee != nil {
+		gasPrice = new(big.Int).Add(header.BaseFee, tx.EffectiveGasTipValue(header.BaseFee))
+	}
+	status := &txStatus{
+		Status:      "mined",
+		BlockNumber: receipt.BlockNumber,
+		GasUsed:     receipt.GasUsed,
+		GasPrice:    gasPrice,
+		Fee:         new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(receipt.GasUsed)),
+	}
+	if head >= receipt.BlockNumber.Uint64() {
+		status.Confirmations = head - receipt.BlockNumber.Uint64() + 1
+	}
+	//4. 执行失败时解析revert原因
+	if receipt.Status == types.ReceiptStatusFailed {
+		status.Status = "failed"
+		status.RevertReason = revertReason(ctx, ethcli, tx, receipt)
+	}
+	return status, nil
+}
+
+//在交易所在区块的父区块状态上重放交易, 解析revert原因
+//重放时不包含同一区块中排在该交易之前的交易, 其结果可能与实际执行不同(甚至不回滚), 只能作为参考
+func revertReason(ctx context.Context, ethcli *ethclient.Client, tx *types.Transaction, receipt *types.Receipt) string {
+	from, err := ethcli.TransactionSender(ctx, tx, receipt.BlockHash, receipt.TransactionIndex)
+	if err != nil {
+		return "unknown"
+	}
+	msg := ethereum.CallMsg{
+		From:     from,
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	}
+	parent := new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1))
+	result, err := ethcli.CallContract(ctx, msg, parent)
+	if err == nil {
+		//部分节点不返回错误, 而是直接返回revert数据
+		if reason, err := abi.UnpackRevert(result); err == nil {
+			return reason
+		}
+		return "unknown"
+	}
+	//geth在rpc错误的data字段中返回revert数据
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			if reason, err := abi.UnpackRevert(common.FromHex(data)); err == nil {
+				return reason
+			}
+			return hexutil.Encode(common.FromHex(data))
+		}
+	}
+	return err.Error()
+}
+
+//查询交易状态命令
+func (c CmdClient) txStatus(hash string) error {
+	_, ethcli, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer ethcli.Close()
+	status, err := queryTxStatus(context.Background(), ethcli, common.HexToHash(hash))
+	if err != nil {
+		return err
+	}
+	fmt.Println(status)
+	return nil
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"wallet/hdwallet"
+	"wallet/nonce"
+)
+
+//节点替换同一nonce交易要求的最小手续费涨幅(%), 与geth txpool的默认PriceBump一致
+const minPriceBump = 10
+
+//普通转账的gas用量
+const transferGas = 21000
+
+//交易已不在交易池中, 无法替换
+var ErrTxNotPending = errors.New("transaction is not pending")
+
+//以更高手续费重签同一nonce的交易; cancel为true时改为向自己转账0 ETH
+//gasLimit不为0时替代原交易(或取消交易)的gas上限
+func (c CmdClient) replaceTx(hash string, cancel bool, bump, gasLimit uint64) (*types.Transaction, error) {
+	if bump < minPriceBump {
+		return nil, fmt.Errorf("price bump %d%% is below the node minimum of %d%%", bump, minPriceBump)
+	}
+	//1. 连接节点并查询原交易
+	rpccli, ethcli, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer ethcli.Close()
+	ctx := context.Background()
+	oldTx, isPending, err := ethcli.TransactionByHash(ctx, common.HexToHash(hash))
+	if err == ethereum.NotFound || (err == nil && !isPending) {
+		return nil, fmt.Errorf("%w: %s", ErrTxNotPending, hash)
+	}
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := chainIDOf(ethcli, c.profile.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), oldTx)
+	if err != nil {
+		return nil, err
+	}
+	//2. 计算新手续费: 原手续费上涨bump%, 且不低于当前建议值
+	current, err := suggestFees(ctx, rpccli, ethcli, feeOverrides{})
+	if err != nil {
+		return nil, err
+	}
+	fees := bumpFees(oldTx, current, bump)
+	//3. 构造替换交易
+	to, value, data, gas := oldTx.To(), oldTx.Value(), oldTx.Data(), oldTx.Gas()
+	if cancel {
+		to, value, data, gas = &from, big.NewInt(0), nil, transferGas
+	}
+	if gasLimit != 0 {
+		gas = gasLimit
+	}
+	if to == nil {
+		return nil, errors.New("replacing contract creation is not supported")
+	}
+	fees.printEstimate(gas)
+	tx := fees.newTx(chainID, oldTx.Nonce(), *to, value, gas, data)
+	//4. 签名并发送
+	w, err := hdwallet.LoadWallet(from.Hex(), c.dataDir, c.passwords)
+	if err != nil {
+		return nil, err
+	}
+	signedTx, err := w.HDKeystore.SignTx(from, tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ethcli.SendTransaction(ctx, signedTx); err != nil {
+		return nil, err
+	}
+	//替换交易覆盖nonce管理器中原交易的记录
+	return signedTx, nonce.NewManager(c.dataDir).Commit(from, oldTx.Nonce(), signedTx.Hash())
+}
+
+//原交易各项手续费上涨bump%, 与当前建议值取较大者; 交易类型跟随当前网络
+func bumpFees(oldTx *types.Transaction, current *txFees, bump uint64) *txFees {
+	raise := func(v *big.Int) *big.Int {
+		r := new(big.Int).Mul(v, new(big.Int).SetUint64(100+bump))
+		//向上取整, 避免因舍入低于节点要求
+		return r.Add(r, big.NewInt(99)).Div(r, big.NewInt(100))
+	}
+	if current.GasFeeCap == nil {
+		return &txFees{GasPrice: maxBig(raise(oldTx.GasPrice()), current.GasPrice)}
+	}
+	//legacy交易的GasFeeCap与GasTipCap均为gasPrice
+	tip := maxBig(raise(oldTx.GasTipCap()), current.GasTipCap)
+	feeCap := maxBig(raise(oldTx.GasFeeCap()), current.GasFeeCap)
+	return &txFees{GasFeeCap: maxBig(feeCap, tip), GasTipCap: tip}
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
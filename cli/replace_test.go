@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"testing"
+)
+
+func TestBumpFees(t *testing.T) {
+	to := common.HexToAddress("0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0")
+	legacyTx := func(gasPrice int64) *types.Transaction {
+		return types.NewTransaction(0, to, big.NewInt(0), transferGas, big.NewInt(gasPrice), nil)
+	}
+	dynamicTx := func(feeCap, tip int64) *types.Transaction {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			GasFeeCap: big.NewInt(feeCap),
+			GasTipCap: big.NewInt(tip),
+			Gas:       transferGas,
+			To:        &to,
+			Value:     big.NewInt(0),
+		})
+	}
+	legacy := func(gasPrice int64) *txFees {
+		return &txFees{GasPrice: big.NewInt(gasPrice)}
+	}
+	dynamic := func(feeCap, tip int64) *txFees {
+		return &txFees{GasFeeCap: big.NewInt(feeCap), GasTipCap: big.NewInt(tip)}
+	}
+	tests := []struct {
+		name    string
+		oldTx   *types.Transaction
+		current *txFees
+		bump    uint64
+		want    *txFees
+	}{
+		//legacy -> legacy: 上涨10%
+		{"legacy bump", legacyTx(100), legacy(50), 10, legacy(110)},
+		//当前建议值更高时取建议值
+		{"legacy current higher", legacyTx(100), legacy(200), 10, legacy(200)},
+		//向上取整: 101 * 1.1 = 111.1 -> 112
+		{"legacy round up", legacyTx(101), legacy(1), 10, legacy(112)},
+		{"legacy 1 wei", legacyTx(1), legacy(1), 10, legacy(2)},
+		{"legacy bump 25%", legacyTx(100), legacy(1), 25, legacy(125)},
+		//EIP-1559 -> EIP-1559: feeCap与tip分别上涨
+		{"dynamic bump", dynamicTx(200, 10), dynamic(100, 5), 10, dynamic(220, 11)},
+		{"dynamic round up", dynamicTx(205, 3), dynamic(1, 1), 10, dynamic(226, 4)},
+		{"dynamic current tip higher", dynamicTx(200, 10), dynamic(100, 50), 10, dynamic(220, 50)},
+		{"dynamic current fee cap higher", dynamicTx(200, 10), dynamic(500, 5), 10, dynamic(500, 11)},
+		//feeCap不低于tip
+		{"dynamic fee cap below tip", dynamicTx(100, 10), dynamic(100, 300), 10, dynamic(300, 300)},
+		//legacy -> EIP-1559: 原gasPrice同时作为feeCap与tip上涨
+		{"legacy to dynamic", legacyTx(100), dynamic(60, 2), 10, dynamic(110, 110)},
+		{"legacy to dynamic current higher", legacyTx(100), dynamic(300, 200), 10, dynamic(300, 200)},
+	}
+	for _, tt := range tests {
+		got := bumpFees(tt.oldTx, tt.current, tt.bump)
+		if got.String() != tt.want.String() || !equalBig(got.GasPrice, tt.want.GasPrice) ||
+			!equalBig(got.GasFeeCap, tt.want.GasFeeCap) || !equalBig(got.GasTipCap, tt.want.GasTipCap) {
+			t.Errorf("%s: bumpFees = %+v, want %+v", tt.name, got, tt.want)
+		}
+		//替换交易的各项手续费至少比原交易高bump%, 满足节点的替换规则
+		if got.GasFeeCap == nil {
+			checkBump(t, tt.name, tt.oldTx.GasPrice(), got.GasPrice, tt.bump)
+		} else {
+			checkBump(t, tt.name, tt.oldTx.GasFeeCap(), got.GasFeeCap, tt.bump)
+			checkBump(t, tt.name, tt.oldTx.GasTipCap(), got.GasTipCap, tt.bump)
+		}
+	}
+}
+
+func equalBig(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Cmp(b) == 0
+}
+
+//replacement * 100 >= old * (100 + bump)
+func checkBump(t *testing.T, name string, old, replacement *big.Int, bump uint64) {
+	lhs := new(big.Int).Mul(replacement, big.NewInt(100))
+	rhs := new(big.Int).Mul(old, new(big.Int).SetUint64(100+bump))
+	if lhs.Cmp(rhs) < 0 {
+		t.Errorf("%s: replacement fee %s is less than %d%% above %s", name, replacement, bump, old)
+	}
+}
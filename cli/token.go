@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"math/big"
+	"sort"
+	"strings"
+	"wallet/hdwallet"
+	"wallet/nonce"
+	"wallet/sol"
+	"wallet/token"
+)
+
+//未指定-token时使用的代币别名
+const defaultToken = "lelecoin"
+
+//解析-token参数: 合约地址或当前网络配置中的代币别名, 并通过注册表获取代币信息
+func (c CmdClient) resolveToken(ctx context.Context, ethcli *ethclient.Client, spec string) (*token.Info, error) {
+	var addr common.Address
+	if common.IsHexAddress(spec) {
+		addr = common.HexToAddress(spec)
+	} else {
+		var err error
+		if addr, err = c.tokenAddress(spec); err != nil {
+			return nil, err
+		}
+	}
+	chainID, err := ethcli.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return token.NewRegistry(c.dataDir).Lookup(ctx, ethcli, chainID, addr)
+}
+
+//当前网络配置的所有代币, 按别名排序, 无法读取的代币打印警告后跳过
+func (c CmdClient) configuredTokens(ctx context.Context, ethcli *ethclient.Client) []*token.Info {
+	aliases := make([]string, 0, len(c.profile.Tokens))
+	for alias := range c.profile.Tokens {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	var infos []*token.Info
+	for _, alias := range aliases {
+		info, err := c.resolveToken(ctx, ethcli, alias)
+		if err != nil {
+			fmt.Printf("warning: skipped token %s: %v\n", alias, err)
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+//带单位的代币数量
+func formatToken(v *big.Int, info *token.Info) string {
+	return formatDecimal(v, int(info.Decimals)) + " " + info.Symbol
+}
+
+//打印代币信息
+func (c CmdClient) tokenInfo(spec string) error {
+	_, ethcli, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer ethcli.Close()
+	info, err := c.resolveToken(context.Background(), ethcli, spec)
+	if err != nil {
+		return err
+	}
+	fmt.Println("address: ", info.Address.Hex())
+	fmt.Println("name: ", info.Name)
+	fmt.Println("symbol: ", info.Symbol)
+	fmt.Println("decimals: ", info.Decimals)
+	return nil
+}
+
+//以from身份调用代币合约的写方法method, 见transact
+func (c CmdClient) transactToken(rpccli *rpc.Client, cli *ethclient.Client, info *token.Info, from string, chainID int64, overrides feeOverrides, method string, params ...interface{}) (*types.Transaction, error) {
+	erc20, err := sol.NewERC20(info.Address, cli)
+	if err != nil {
+		return nil, err
+	}
+	//估算gas需要method的calldata
+	erc20ABI, err := abi.JSON(strings.NewReader(sol.ERC20ABI))
+	if err != nil {
+		return nil, err
+	}
+	data, err := erc20ABI.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	return c.transact(rpccli, cli, from, chainID, overrides, &info.Address, data, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return (&sol.ERC20TransactorRaw{Contract: &erc20.ERC20Transactor}).Transact(auth, method, params...)
+	})
+}
+
+//以from身份发送合约交易, 负责加载钱包、分配nonce、估算手续费与gas并设置签名身份, 由send通过合约绑定发送
+//to与data用于估算gas, 部署合约时to为nil; chainID为0时使用网络配置或节点返回的链ID
+func (c CmdClient) transact(rpccli *rpc.Client, cli *ethclient.Client, from string, chainID int64, overrides feeOverrides, to *common.Address, data []byte, send func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	chainIDBig, err := chainIDOf(cli, c.chainID(chainID))
+	if err != nil {
+		return nil, err
+	}
+	//1. 设置调用身份
+	//1.1 钱包加载
+	w, err := hdwallet.LoadWallet(from, c.dataDir, c.passwords)
+	if err != nil {
+		return nil, err
+	}
+	//1.2 利用钱包私钥创建身份
+	nonceMgr := nonce.NewManager(c.dataDir)
+	txNonce, err := c.nextNonce(nonceMgr, cli, common.HexToAddress(from))
+	if err != nil {
+		return nil, err
+	}
+	fees, err := suggestFees(context.Background(), rpccli, cli, overrides)
+	if err != nil {
+		return nil, err
+	}
+	//2. 估算gas
+	gasLimit, err := fees.estimateGas(context.Background(), cli, ethereum.CallMsg{
+		From: common.HexToAddress(from),
+		To:   to,
+		Data: data,
+	}, overrides)
+	if err != nil {
+		return nil, err
+	}
+	fees.printEstimate(gasLimit)
+
+	auth, err := w.HDKeystore.NewTransactOpts(chainIDBig)
+	if err != nil {
+		return nil, err
+	}
+	auth.Nonce = new(big.Int).SetUint64(txNonce)
+	//注意必须设定auth.Context, 否则报错-> nil Context!!!
+	auth.Context = context.Background()
+
+	auth.Value = big.NewInt(0) // in wei
+	auth.GasLimit = gasLimit   // in units
+	//注意必须设置手续费, 否则bind会再次向节点查询!!!
+	fees.apply(auth)
+
+	//3. 发送交易
+	tx, err := send(auth)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nonceMgr.Commit(common.HexToAddress(from), txNonce, tx.Hash())
+}
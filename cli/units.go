@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+//以太币单位及其对应的小数位数
+var etherUnits = map[string]int{
+	"wei":    0,
+	"kwei":   3,
+	"mwei":   6,
+	"gwei":   9,
+	"szabo":  12,
+	"finney": 15,
+	"ether":  18,
+	"eth":    18,
+}
+
+//十进制数, 不接受符号、指数、分数、0x等进制前缀与下划线分隔
+var decimalRe = regexp.MustCompile(`^\d*\.?\d+$`)
+
+//解析带单位的以太币数量, 如"1.5ether"、"30gwei"、"100wei", 无单位时按defaultUnit解析
+func parseEtherAmount(s, defaultUnit string) (*big.Int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	num, unit := s, defaultUnit
+	//从末尾截取字母部分作为单位
+	i := len(s)
+	for i > 0 && s[i-1] >= 'a' && s[i-1] <= 'z' {
+		i--
+	}
+	if i < len(s) {
+		num, unit = strings.TrimSpace(s[:i]), s[i:]
+	}
+	decimals, ok := etherUnits[unit]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q in amount %q", unit, s)
+	}
+	return parseDecimal(num, decimals)
+}
+
+//解析可选的带单位以太币数量, 空串返回nil
+func parseOptionalEtherAmount(s, defaultUnit string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return parseEtherAmount(s, defaultUnit)
+}
+
+//将十进制数(可含小数)乘以10^decimals转换为整数, 小数位超出decimals时报错
+func parseDecimal(s string, decimals int) (*big.Int, error) {
+	if !decimalRe.MatchString(s) {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(pow10(decimals)))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("amount %q has more than %d decimals", s, decimals)
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
+
+//将整数按decimals位小数格式化, 去掉末尾多余的0
+func formatDecimal(v *big.Int, decimals int) string {
+	if v == nil {
+		return "0"
+	}
+	sign := ""
+	abs := new(big.Int).Set(v)
+	if abs.Sign() < 0 {
+		sign = "-"
+		abs.Neg(abs)
+	}
+	q, r := new(big.Int).QuoRem(abs, pow10(decimals), new(big.Int))
+	if r.Sign() == 0 {
+		return sign + q.String()
+	}
+	frac := fmt.Sprintf("%0*s", decimals, r.String())
+	return sign + q.String() + "." + strings.TrimRight(frac, "0")
+}
+
+//以ETH为单位格式化wei
+func formatEther(wei *big.Int) string {
+	return formatDecimal(wei, etherUnits["ether"]) + " ETH"
+}
+
+//以gwei为单位格式化wei
+func formatGwei(wei *big.Int) string {
+	return formatDecimal(wei, etherUnits["gwei"]) + " gwei"
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
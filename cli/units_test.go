@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in       string
+		decimals int
+		want     string
+	}{
+		{"0", 18, "0"},
+		{"1", 0, "1"},
+		{"1.5", 18, "1500000000000000000"},
+		{".5", 1, "5"},
+		{"0.000000000000000001", 18, "1"},
+		{"007", 2, "700"},
+		{"123456789012345678901234567890", 0, "123456789012345678901234567890"},
+	}
+	for _, tt := range tests {
+		got, err := parseDecimal(tt.in, tt.decimals)
+		if err != nil {
+			t.Errorf("parseDecimal(%q, %d) error: %v", tt.in, tt.decimals, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("parseDecimal(%q, %d) = %s, want %s", tt.in, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecimalInvalid(t *testing.T) {
+	tests := []struct {
+		in       string
+		decimals int
+	}{
+		{"", 18},
+		{".", 18},
+		{"1.", 18},
+		{"-1", 18},
+		{"+1", 18},
+		{"1e18", 18},
+		{"1E3", 18},
+		{"1/2", 18},
+		{"0x10", 18},
+		{"0b101", 18},
+		{"0o17", 18},
+		{"1_000", 18},
+		{"1.2.3", 18},
+		{" 1", 18},
+		{"1,5", 18},
+		{"١", 18},
+		{"1.5", 0},
+		{"0.0000000000000000001", 18},
+	}
+	for _, tt := range tests {
+		if got, err := parseDecimal(tt.in, tt.decimals); err == nil {
+			t.Errorf("parseDecimal(%q, %d) = %s, want error", tt.in, tt.decimals, got)
+		}
+	}
+}
+
+func TestParseEtherAmount(t *testing.T) {
+	tests := []struct {
+		in, unit string
+		want     string
+		ok       bool
+	}{
+		{"1", "ether", "1000000000000000000", true},
+		{"1.5ether", "wei", "1500000000000000000", true},
+		{"1.5 ETH", "wei", "1500000000000000000", true},
+		{"30gwei", "ether", "30000000000", true},
+		{" 2.5 Gwei ", "ether", "2500000000", true},
+		{"100wei", "ether", "100", true},
+		{"100", "wei", "100", true},
+		{"1finney", "ether", "1000000000000000", true},
+		{"1.5wei", "ether", "", false},
+		{"1btc", "ether", "", false},
+		{"gwei", "ether", "", false},
+		{"0x10", "wei", "", false},
+		{"0x10gwei", "ether", "", false},
+		{"1_000gwei", "ether", "", false},
+		{"1e9wei", "ether", "", false},
+		{"-1ether", "wei", "", false},
+	}
+	for _, tt := range tests {
+		got, err := parseEtherAmount(tt.in, tt.unit)
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("parseEtherAmount(%q, %q) = %s, want error", tt.in, tt.unit, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseEtherAmount(%q, %q) error: %v", tt.in, tt.unit, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("parseEtherAmount(%q, %q) = %s, want %s", tt.in, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	tests := []struct {
+		in       *big.Int
+		decimals int
+		want     string
+	}{
+		{nil, 18, "0"},
+		{big.NewInt(0), 18, "0"},
+		{big.NewInt(5), 0, "5"},
+		{big.NewInt(1), 18, "0.000000000000000001"},
+		{big.NewInt(1500), 3, "1.5"},
+		{big.NewInt(1000), 3, "1"},
+		{big.NewInt(1050), 2, "10.5"},
+		{big.NewInt(-1500), 3, "-1.5"},
+		{big.NewInt(-1), 2, "-0.01"},
+	}
+	for _, tt := range tests {
+		if got := formatDecimal(tt.in, tt.decimals); got != tt.want {
+			t.Errorf("formatDecimal(%v, %d) = %s, want %s", tt.in, tt.decimals, got, tt.want)
+		}
+	}
+	//格式化后再解析得到原值
+	v, _ := new(big.Int).SetString("123456789000000000001", 10)
+	got, err := parseDecimal(formatDecimal(v, 18), 18)
+	if err != nil || got.Cmp(v) != 0 {
+		t.Fatalf("round trip = %v, %v, want %s", got, err, v)
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"io/ioutil"
+	"sort"
+	"wallet/hdkeystore"
+)
+
+//默认配置文件, 位于当前目录
+const FileName = "config.json"
+
+//未指定配置文件或配置项时使用的默认值
+const (
+	DefaultNetwork = "local"
+	DefaultRPC     = "http://localhost:8545"
+	DefaultDataDir = "./keystore"
+)
+
+//配置文件中没有该网络
+var ErrUnknownNetwork = errors.New("unknown network")
+
+//一个以太坊网络的连接参数
+type Network struct {
+	//节点RPC地址
+	RPC string `json:"rpc"`
+	//链ID, 为0时使用节点返回的链ID
+	ChainID int64 `json:"chainId,omitempty"`
+	//区块浏览器地址, 如https://etherscan.io, 为空时不输出交易链接
+	Explorer string `json:"explorer,omitempty"`
+	//代币名称到合约地址的映射
+	Tokens map[string]common.Address `json:"tokens,omitempty"`
+}
+
+//配置文件内容
+type Config struct {
+	//未通过-network指定时使用的网络
+	DefaultNetwork string `json:"defaultNetwork,omitempty"`
+	//keystore与本地数据目录
+	DataDir string `json:"datadir,omitempty"`
+	//网络名称到网络参数的映射
+	Networks map[string]*Network `json:"networks"`
+}
+
+//没有配置文件时的默认配置, 与原先写死的节点地址与合约地址一致
+func Default() *Config {
+	return &Config{
+		DefaultNetwork: DefaultNetwork,
+		DataDir:        DefaultDataDir,
+		Networks: map[string]*Network{
+			DefaultNetwork: {
+				RPC: DefaultRPC,
+				Tokens: map[string]common.Address{
+					"lelecoin": common.HexToAddress("0x9B4E5A473d60D2D696F82d224723769d25F104c2"),
+				},
+			},
+		},
+	}
+}
+
+//读取JSON配置文件
+func Load(filename string) (*Config, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	if err := json.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	if cfg.Networks == nil {
+		cfg.Networks = make(map[string]*Network)
+	}
+	return cfg, nil
+}
+
+//写入配置文件, 通过WriteKeyFile原子替换
+func (cfg *Config) Save(filename string) error {
+	content, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return hdkeystore.WriteKeyFile(filename, append(content, '\n'))
+}
+
+//按名称获取网络, name为空时使用默认网络
+func (cfg *Config) Network(name string) (*Network, error) {
+	if name == "" {
+		name = cfg.DefaultNetwork
+	}
+	net, ok := cfg.Networks[name]
+	if !ok || net == nil {
+		return nil, fmt.Errorf("%w: %q, configured: %v", ErrUnknownNetwork, name, cfg.NetworkNames())
+	}
+	return net, nil
+}
+
+//按名称排序的网络列表
+func (cfg *Config) NetworkNames() []string {
+	names := make([]string, 0, len(cfg.Networks))
+	for name := range cfg.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,4 +1,4 @@
-pragma solidity ^0.6.0;
+pragma solidity >=0.6.0 <0.9.0;
 
 import "./SafeMath.sol";
 import "./IERC20.sol";
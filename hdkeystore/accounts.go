@@ -0,0 +1,80 @@
+package hdkeystore
+
+import (
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//keystore目录中的账户
+type Account struct {
+	Address common.Address
+	//keystore文件完整路径
+	File string
+}
+
+//keystore文件中无需解密即可读取的头部信息
+type keyHeaderJSON struct {
+	Address string          `json:"address"`
+	Crypto  json.RawMessage `json:"crypto"`
+	Id      string          `json:"id"`
+	Version int             `json:"version"`
+}
+
+//扫描keystore目录, 只解析文件头部而不解密, 按地址排序返回所有账户
+func (ks HDKeyStore) Accounts() ([]Account, error) {
+	files, err := ioutil.ReadDir(ks.keyDirPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var accounts []Account
+	for _, fi := range files {
+		//跳过目录、隐藏文件(含写入中的临时文件)与种子文件
+		name := fi.Name()
+		if fi.IsDir() || strings.HasPrefix(name, ".") || name == SeedFileName {
+			continue
+		}
+		filename := ks.JoinPath(name)
+		addr, ok := readKeyAddress(filename)
+		if !ok {
+			continue
+		}
+		accounts = append(accounts, Account{Address: addr, File: filename})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return strings.ToLower(accounts[i].Address.Hex()) < strings.ToLower(accounts[j].Address.Hex())
+	})
+	return accounts, nil
+}
+
+//读取keystore文件头部中的地址, 非keystore文件返回false
+//早期生成的文件address字段为空, 此时以文件名作为地址
+func readKeyAddress(filename string) (common.Address, bool) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return common.Address{}, false
+	}
+	defer f.Close()
+	var header keyHeaderJSON
+	//只解码第一个JSON对象, 容忍文件末尾的多余内容
+	if err := json.NewDecoder(f).Decode(&header); err != nil {
+		return common.Address{}, false
+	}
+	if len(header.Crypto) == 0 || header.Version != 3 {
+		return common.Address{}, false
+	}
+	if common.IsHexAddress(header.Address) {
+		return common.HexToAddress(header.Address), true
+	}
+	if base := filepath.Base(filename); common.IsHexAddress(base) {
+		return common.HexToAddress(base), true
+	}
+	return common.Address{}, false
+}
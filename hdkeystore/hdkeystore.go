@@ -2,6 +2,8 @@ package hdkeystore
 
 import (
 	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
@@ -15,13 +17,18 @@ import (
 	"path/filepath"
 )
 
+var (
+	//keystore目录中没有该账户的文件
+	ErrAccountNotFound = errors.New("account not found")
+	//口令错误, 无法解密keystore文件
+	ErrWrongPassword = errors.New("wrong password")
+)
+
 type HDKeyStore struct {
 	//文件所在路径
 	keyDirPath string
-	//生成加密文件的参数N
-	scryptN int
-	//生成加密文件的参数P
-	scryptP int
+	//生成加密文件的密钥派生参数
+	kdf KDF
 	//keystore对应的key
 	Key keystore.Key
 }
@@ -35,8 +42,7 @@ func NewHDKeyStore(path string, privateKey *ecdsa.PrivateKey) *HDKeyStore {
 
 	return &HDKeyStore{
 		keyDirPath: path,
-		scryptN:    keystore.LightScryptN, //固定参数
-		scryptP:    keystore.LightScryptP, //固定参数
+		kdf:        LightKDF, //默认参数, 可通过SetKDF修改
 		Key:        key,
 	}
 }
@@ -45,8 +51,7 @@ func NewHDKeyStore(path string, privateKey *ecdsa.PrivateKey) *HDKeyStore {
 func NewHDKeyStoreWithoutKey(path string) *HDKeyStore {
 	return &HDKeyStore{
 		keyDirPath: path,
-		scryptN:    keystore.LightScryptN,
-		scryptP:    keystore.LightScryptP,
+		kdf:        LightKDF,
 		Key:        keystore.Key{},
 	}
 }
@@ -54,10 +59,9 @@ func NewHDKeyStoreWithoutKey(path string) *HDKeyStore {
 //储存Key为keystore文件
 func (ks HDKeyStore) StoreKey(filename string, key *keystore.Key, auth string) error {
 	//编码
-	keyjson, err := keystore.EncryptKey(key, auth, ks.scryptN, ks.scryptP)
+	keyjson, err := encryptKey(key, auth, ks.kdf)
 	if err != nil {
-		fmt.Println("Failed to EncryptKey: ", err)
-		return err
+		return fmt.Errorf("failed to encrypt key: %w", err)
 	}
 	//写入文件
 	return WriteKeyFile(filename, keyjson)
@@ -85,6 +89,11 @@ func WriteKeyFile(file string, content []byte) error {
 	return os.Rename(f.Name(), file)
 }
 
+//keystore文件所在目录
+func (ks HDKeyStore) KeyDirPath() string {
+	return ks.keyDirPath
+}
+
 //实现路径拼接接口，用于将路径与文件名拼接
 func (ks HDKeyStore) JoinPath(filename string) string {
 	//如果filename是绝对路径则直接返回
@@ -98,11 +107,17 @@ func (ks HDKeyStore) JoinPath(filename string) string {
 //实现keystore文件解析接口
 func (ks *HDKeyStore) GetKey(addr common.Address, filename, auth string) (*keystore.Key, error) {
 	keyjson, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, addr.Hex())
+	}
 	if err != nil {
 		return nil, err
 	}
 	//利用以太坊DecryptKey解码json文件
 	key, err := keystore.DecryptKey(keyjson, auth)
+	if err == keystore.ErrDecrypt {
+		return nil, fmt.Errorf("%w for %s", ErrWrongPassword, addr.Hex())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -114,25 +129,75 @@ func (ks *HDKeyStore) GetKey(addr common.Address, filename, auth string) (*keyst
 	return key, nil
 }
 
-//交易签名方法
+//修改keystore文件口令: 用旧口令解密后以新口令重新加密, 通过WriteKeyFile原子替换原文件
+//沿用文件中原有的密钥派生参数
+func (ks *HDKeyStore) ChangePassword(addr common.Address, filename, oldAuth, newAuth string) error {
+	kdf, err := ReadKDF(filename)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, addr.Hex())
+	}
+	if err != nil {
+		return err
+	}
+	return ks.Rekey(addr, filename, oldAuth, newAuth, kdf)
+}
+
+//以新的密钥派生参数和口令重新加密keystore文件, 原子替换原文件
+func (ks *HDKeyStore) Rekey(addr common.Address, filename, oldAuth, newAuth string, kdf KDF) error {
+	if err := kdf.Validate(); err != nil {
+		return err
+	}
+	key, err := ks.GetKey(addr, filename, oldAuth)
+	if err != nil {
+		return err
+	}
+	keyjson, err := encryptKey(key, newAuth, kdf)
+	if err != nil {
+		return err
+	}
+	return WriteKeyFile(filename, keyjson)
+}
+
+//导出账户私钥, 返回不带0x前缀的16进制字符串
+func (ks *HDKeyStore) ExportPrivateKey(addr common.Address, filename, auth string) (string, error) {
+	key, err := ks.GetKey(addr, filename, auth)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(crypto.FromECDSA(key.PrivateKey)), nil
+}
+
+//导出为标准V3 keystore JSON, 以newAuth加密, 可被geth等客户端导入
+func (ks *HDKeyStore) ExportKeyJSON(addr common.Address, filename, auth, newAuth string) ([]byte, error) {
+	key, err := ks.GetKey(addr, filename, auth)
+	if err != nil {
+		return nil, err
+	}
+	return encryptKey(key, newAuth, ks.kdf)
+}
+
+//交易签名方法, 根据chainID选择签名器(EIP-155及之后的交易类型), chainID为nil时使用Homestead签名
 func (ks *HDKeyStore) SignTx(address common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
-	signedTx, err := types.SignTx(tx, types.HomesteadSigner{}, ks.Key.PrivateKey)
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.LatestSignerForChainID(chainID)
+	}
+	signedTx, err := types.SignTx(tx, signer, ks.Key.PrivateKey)
 	if err != nil {
 		return nil, err
 	}
 	//验证签名
-	msg, err := signedTx.AsMessage(types.HomesteadSigner{}, nil)
+	sender, err := types.Sender(signer, signedTx)
 	if err != nil {
 		return nil, err
 	}
-	sender := msg.From()
 	if sender != address {
 		return nil, fmt.Errorf("signer mismatch: expected %s, got %s", address.Hex(), sender.Hex())
 	}
 	return signedTx, nil
 }
 
-//利用keystore生成token合约调用身份
-func (ks HDKeyStore) NewTransactOpts() *bind.TransactOpts {
-	return bind.NewKeyedTransactor(ks.Key.PrivateKey)
-}
\ No newline at end of file
+//利用keystore生成token合约调用身份, 交易按chainID做EIP-155签名
+func (ks HDKeyStore) NewTransactOpts(chainID *big.Int) (*bind.TransactOpts, error) {
+	return bind.NewKeyedTransactorWithChainID(ks.Key.PrivateKey, chainID)
+}
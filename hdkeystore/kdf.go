@@ -0,0 +1,207 @@
+package hdkeystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/pbkdf2"
+	"io"
+	"os"
+)
+
+//支持的密钥派生算法
+const (
+	KDFScrypt = "scrypt"
+	KDFPBKDF2 = "pbkdf2"
+)
+
+//PBKDF2默认迭代次数
+const DefaultPBKDF2C = 262144
+
+//加密keystore文件时的密钥派生参数, 参数随kdfparams记录在每个文件中
+type KDF struct {
+	//scrypt或pbkdf2
+	Name string
+	//scrypt参数N与P
+	N int
+	P int
+	//pbkdf2迭代次数
+	C int
+}
+
+var (
+	//以太坊keystore的light参数, 速度快但强度低, 仅适合测试
+	LightKDF = KDF{Name: KDFScrypt, N: keystore.LightScryptN, P: keystore.LightScryptP}
+	//以太坊keystore的standard参数, 与geth默认一致
+	StandardKDF = KDF{Name: KDFScrypt, N: keystore.StandardScryptN, P: keystore.StandardScryptP}
+	//PBKDF2-HMAC-SHA256
+	PBKDF2KDF = KDF{Name: KDFPBKDF2, C: DefaultPBKDF2C}
+)
+
+//按名称获取预设参数: light/standard/pbkdf2
+func KDFByName(name string) (KDF, error) {
+	switch name {
+	case "light":
+		return LightKDF, nil
+	case "standard":
+		return StandardKDF, nil
+	case KDFPBKDF2:
+		return PBKDF2KDF, nil
+	}
+	return KDF{}, fmt.Errorf("unknown kdf %q, want light, standard or pbkdf2", name)
+}
+
+//校验参数合法性
+func (k KDF) Validate() error {
+	switch k.Name {
+	case KDFScrypt:
+		//N必须是大于1的2的幂
+		if k.N <= 1 || k.N&(k.N-1) != 0 {
+			return fmt.Errorf("scrypt N must be a power of 2 greater than 1, got %d", k.N)
+		}
+		if k.P <= 0 {
+			return fmt.Errorf("scrypt P must be positive, got %d", k.P)
+		}
+	case KDFPBKDF2:
+		if k.C <= 0 {
+			return fmt.Errorf("pbkdf2 iteration count must be positive, got %d", k.C)
+		}
+	default:
+		return fmt.Errorf("unsupported kdf %q", k.Name)
+	}
+	return nil
+}
+
+func (k KDF) String() string {
+	if k.Name == KDFPBKDF2 {
+		return fmt.Sprintf("pbkdf2(c=%d)", k.C)
+	}
+	return fmt.Sprintf("scrypt(n=%d, p=%d)", k.N, k.P)
+}
+
+//k是否弱于other: 同为scrypt时N或N*P变小即为更弱, 同为pbkdf2时比较迭代次数
+//pbkdf2不消耗内存, 由scrypt改为pbkdf2视为更弱, 反之不是
+func (k KDF) Weaker(other KDF) bool {
+	switch {
+	case k.Name == KDFScrypt && other.Name == KDFScrypt:
+		return k.N < other.N || k.N*k.P < other.N*other.P
+	case k.Name == KDFPBKDF2 && other.Name == KDFPBKDF2:
+		return k.C < other.C
+	}
+	return k.Name == KDFPBKDF2 && other.Name == KDFScrypt
+}
+
+//设置之后写入文件时使用的密钥派生参数
+func (ks *HDKeyStore) SetKDF(kdf KDF) error {
+	if err := kdf.Validate(); err != nil {
+		return err
+	}
+	ks.kdf = kdf
+	return nil
+}
+
+//当前使用的密钥派生参数
+func (ks HDKeyStore) KDF() KDF {
+	return ks.kdf
+}
+
+//读取文件中记录的密钥派生参数, 无需解密
+func ReadKDF(filename string) (KDF, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return KDF{}, err
+	}
+	defer f.Close()
+	var header struct {
+		Crypto struct {
+			KDF       string `json:"kdf"`
+			KDFParams struct {
+				N int `json:"n"`
+				P int `json:"p"`
+				C int `json:"c"`
+			} `json:"kdfparams"`
+		} `json:"crypto"`
+	}
+	if err := json.NewDecoder(f).Decode(&header); err != nil {
+		return KDF{}, err
+	}
+	params := header.Crypto.KDFParams
+	switch header.Crypto.KDF {
+	case KDFScrypt:
+		return KDF{Name: KDFScrypt, N: params.N, P: params.P}, nil
+	case KDFPBKDF2:
+		return KDF{Name: KDFPBKDF2, C: params.C}, nil
+	}
+	return KDF{}, errors.New("unsupported kdf " + header.Crypto.KDF)
+}
+
+//按kdf参数加密数据, 格式与以太坊keystore的crypto字段一致
+func encryptData(data []byte, auth string, kdf KDF) (keystore.CryptoJSON, error) {
+	if kdf.Name != KDFPBKDF2 {
+		return keystore.EncryptDataV3(data, []byte(auth), kdf.N, kdf.P)
+	}
+	//PBKDF2: 以太坊只提供解密, 加密按Web3 Secret Storage规范自行实现
+	const dkLen = 32
+	salt := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return keystore.CryptoJSON{}, err
+	}
+	derivedKey := pbkdf2.Key([]byte(auth), salt, kdf.C, dkLen, sha256.New)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return keystore.CryptoJSON{}, err
+	}
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		return keystore.CryptoJSON{}, err
+	}
+	cipherText := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(cipherText, data)
+	mac := crypto.Keccak256(derivedKey[16:32], cipherText)
+
+	cryptoStruct := keystore.CryptoJSON{
+		Cipher:     "aes-128-ctr",
+		CipherText: hex.EncodeToString(cipherText),
+		KDF:        KDFPBKDF2,
+		KDFParams: map[string]interface{}{
+			"c":     kdf.C,
+			"dklen": dkLen,
+			"prf":   "hmac-sha256",
+			"salt":  hex.EncodeToString(salt),
+		},
+		MAC: hex.EncodeToString(mac),
+	}
+	cryptoStruct.CipherParams.IV = hex.EncodeToString(iv)
+	return cryptoStruct, nil
+}
+
+//V3 keystore文件格式
+type encryptedKeyJSONV3 struct {
+	Address string              `json:"address"`
+	Crypto  keystore.CryptoJSON `json:"crypto"`
+	Id      string              `json:"id"`
+	Version int                 `json:"version"`
+}
+
+//按kdf参数将Key编码为V3 keystore JSON
+func encryptKey(key *keystore.Key, auth string, kdf KDF) ([]byte, error) {
+	keyBytes := math.PaddedBigBytes(key.PrivateKey.D, 32)
+	cryptoStruct, err := encryptData(keyBytes, auth, kdf)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encryptedKeyJSONV3{
+		Address: hex.EncodeToString(key.Address[:]),
+		Crypto:  cryptoStruct,
+		Id:      key.Id.String(),
+		Version: 3,
+	})
+}
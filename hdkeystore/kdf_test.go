@@ -0,0 +1,171 @@
+package hdkeystore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+//测试用的密钥派生参数, pbkdf2迭代次数较小以加快测试
+var testKDFs = []KDF{
+	LightKDF,
+	{Name: KDFScrypt, N: 1 << 10, P: 2},
+	{Name: KDFPBKDF2, C: 1024},
+}
+
+//以各参数加密的keystore文件可被以太坊keystore解密
+func TestEncryptKeyRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hdkeystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := NewHDKeyStore(dir, privateKey)
+	//同一文件依次以各参数重新写入
+	for _, kdf := range testKDFs {
+		if err := ks.SetKDF(kdf); err != nil {
+			t.Fatal(err)
+		}
+		filename := ks.JoinPath(ks.Key.Address.Hex())
+		if err := ks.StoreKey(filename, &ks.Key, "pw"); err != nil {
+			t.Fatalf("%s: StoreKey: %v", kdf, err)
+		}
+		keyjson, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := keystore.DecryptKey(keyjson, "pw")
+		if err != nil {
+			t.Fatalf("%s: DecryptKey: %v", kdf, err)
+		}
+		if key.Address != ks.Key.Address || key.PrivateKey.D.Cmp(ks.Key.PrivateKey.D) != 0 || key.Id != ks.Key.Id {
+			t.Fatalf("%s: decrypted key does not match", kdf)
+		}
+		if _, err := keystore.DecryptKey(keyjson, "wrong"); err != keystore.ErrDecrypt {
+			t.Fatalf("%s: DecryptKey with wrong password = %v, want ErrDecrypt", kdf, err)
+		}
+		if got, err := ReadKDF(filename); err != nil || got != kdf {
+			t.Fatalf("%s: ReadKDF = %s, %v", kdf, got, err)
+		}
+	}
+}
+
+//以各参数加密的种子文件可被以太坊keystore解密
+func TestSeedVaultRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hdkeystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := NewHDKeyStore(dir, privateKey)
+	//种子文件依次以各参数覆盖
+	for _, kdf := range testKDFs {
+		if err := ks.SetKDF(kdf); err != nil {
+			t.Fatal(err)
+		}
+		seed := &Seed{Seed: bytes.Repeat([]byte{0xab}, 64), Address: ks.Key.Address, Account: 1, NextIndex: 3}
+		if err := ks.StoreSeed(seed, "pw"); err != nil {
+			t.Fatalf("%s: StoreSeed: %v", kdf, err)
+		}
+		seedjson, err := ioutil.ReadFile(ks.JoinPath(SeedFileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sj seedJSON
+		if err := json.Unmarshal(seedjson, &sj); err != nil {
+			t.Fatal(err)
+		}
+		plain, err := keystore.DecryptDataV3(sj.Crypto, "pw")
+		if err != nil || !bytes.Equal(plain, seed.Seed) {
+			t.Fatalf("%s: DecryptDataV3 = %x, %v", kdf, plain, err)
+		}
+		got, err := ks.GetSeed("pw")
+		if err != nil {
+			t.Fatalf("%s: GetSeed: %v", kdf, err)
+		}
+		if !bytes.Equal(got.Seed, seed.Seed) || got.Address != seed.Address || got.Account != 1 || got.NextIndex != 3 {
+			t.Fatalf("%s: GetSeed = %+v", kdf, got)
+		}
+		if current, err := ks.SeedKDF(); err != nil || current != kdf {
+			t.Fatalf("%s: SeedKDF = %s, %v", kdf, current, err)
+		}
+	}
+}
+
+//重新加密后参数改变, 私钥不变
+func TestRekey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hdkeystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := NewHDKeyStore(dir, privateKey)
+	filename := ks.JoinPath(ks.Key.Address.Hex())
+	if err := ks.StoreKey(filename, &ks.Key, "pw"); err != nil {
+		t.Fatal(err)
+	}
+	target := KDF{Name: KDFPBKDF2, C: 1024}
+	if err := ks.Rekey(ks.Key.Address, filename, "pw", "new", target); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadKDF(filename); err != nil || got != target {
+		t.Fatalf("ReadKDF = %s, %v, want %s", got, err, target)
+	}
+	key, err := ks.GetKey(ks.Key.Address, filename, "new")
+	if err != nil || key.PrivateKey.D.Cmp(ks.Key.PrivateKey.D) != 0 {
+		t.Fatalf("GetKey after rekey = %v", err)
+	}
+}
+
+func TestKDFWeaker(t *testing.T) {
+	tests := []struct {
+		k, other KDF
+		want     bool
+	}{
+		{LightKDF, StandardKDF, true},
+		{StandardKDF, LightKDF, false},
+		{StandardKDF, StandardKDF, false},
+		{KDF{Name: KDFScrypt, N: 1 << 18, P: 1}, KDF{Name: KDFScrypt, N: 1 << 18, P: 2}, true},
+		{KDF{Name: KDFScrypt, N: 1 << 17, P: 4}, KDF{Name: KDFScrypt, N: 1 << 18, P: 1}, true},
+		{KDF{Name: KDFPBKDF2, C: 1000}, PBKDF2KDF, true},
+		{PBKDF2KDF, KDF{Name: KDFPBKDF2, C: 1000}, false},
+		{PBKDF2KDF, LightKDF, true},
+		{LightKDF, PBKDF2KDF, false},
+	}
+	for _, tt := range tests {
+		if got := tt.k.Weaker(tt.other); got != tt.want {
+			t.Errorf("%s.Weaker(%s) = %v, want %v", tt.k, tt.other, got, tt.want)
+		}
+	}
+}
+
+func TestChangePasswordMissingAccount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hdkeystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ks := NewHDKeyStoreWithoutKey(dir)
+	addr := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	if err := ks.ChangePassword(addr, ks.JoinPath(addr.Hex()), "pw", "new"); !errors.Is(err, ErrAccountNotFound) {
+		t.Fatalf("ChangePassword = %v, want ErrAccountNotFound", err)
+	}
+}
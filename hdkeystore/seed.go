@@ -0,0 +1,128 @@
+package hdkeystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"io/ioutil"
+	"os"
+)
+
+//种子文件与账户文件放在同一目录下
+const SeedFileName = "seed.json"
+
+//种子文件不存在
+var ErrSeedNotFound = errors.New("seed vault not found")
+
+//种子文件格式, 加密部分与keystore文件相同(scrypt + aes-128-ctr)
+type seedJSON struct {
+	//首个储存账户的地址, 便于识别种子
+	Address string              `json:"address"`
+	Crypto  keystore.CryptoJSON `json:"crypto"`
+	Id      string              `json:"id"`
+	Version int                 `json:"version"`
+	//推导所用BIP-44账户号
+	Account uint32 `json:"account"`
+	//下一个待推导账户的index
+	NextIndex uint32 `json:"nextindex"`
+}
+
+//种子解密后的信息
+type Seed struct {
+	Seed      []byte
+	Address   common.Address
+	Account   uint32
+	NextIndex uint32
+}
+
+//判断目录中是否已有种子文件
+func (ks HDKeyStore) HasSeed() bool {
+	_, err := os.Stat(ks.JoinPath(SeedFileName))
+	return err == nil
+}
+
+//加密并储存种子, 使用当前的密钥派生参数
+func (ks HDKeyStore) StoreSeed(seed *Seed, auth string) error {
+	//编码
+	cryptoStruct, err := encryptData(seed.Seed, auth, ks.kdf)
+	if err != nil {
+		return err
+	}
+	seedjson, err := json.Marshal(seedJSON{
+		Address:   seed.Address.Hex(),
+		Crypto:    cryptoStruct,
+		Id:        uuid.New().String(),
+		Version:   3,
+		Account:   seed.Account,
+		NextIndex: seed.NextIndex,
+	})
+	if err != nil {
+		return err
+	}
+	//写入文件
+	return WriteKeyFile(ks.JoinPath(SeedFileName), seedjson)
+}
+
+//读取并解密种子
+func (ks HDKeyStore) GetSeed(auth string) (*Seed, error) {
+	seedjson, err := ioutil.ReadFile(ks.JoinPath(SeedFileName))
+	if os.IsNotExist(err) {
+		return nil, ErrSeedNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sj seedJSON
+	if err := json.Unmarshal(seedjson, &sj); err != nil {
+		return nil, err
+	}
+	//利用以太坊DecryptDataV3解码
+	seed, err := keystore.DecryptDataV3(sj.Crypto, auth)
+	if err == keystore.ErrDecrypt {
+		return nil, fmt.Errorf("%w for seed vault", ErrWrongPassword)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Seed{
+		Seed:      seed,
+		Address:   common.HexToAddress(sj.Address),
+		Account:   sj.Account,
+		NextIndex: sj.NextIndex,
+	}, nil
+}
+
+//更新已有种子文件的内容, 沿用文件中原有的密钥派生参数
+func (ks HDKeyStore) UpdateSeed(seed *Seed, auth string) error {
+	kdf, err := ks.SeedKDF()
+	if err != nil {
+		return err
+	}
+	ks.kdf = kdf
+	return ks.StoreSeed(seed, auth)
+}
+
+//以新的密钥派生参数和口令重新加密种子文件
+func (ks HDKeyStore) RekeySeed(oldAuth, newAuth string, kdf KDF) error {
+	if err := kdf.Validate(); err != nil {
+		return err
+	}
+	seed, err := ks.GetSeed(oldAuth)
+	if err != nil {
+		return err
+	}
+	ks.kdf = kdf
+	return ks.StoreSeed(seed, newAuth)
+}
+
+//种子文件中记录的密钥派生参数
+func (ks HDKeyStore) SeedKDF() (KDF, error) {
+	kdf, err := ReadKDF(ks.JoinPath(SeedFileName))
+	if os.IsNotExist(err) {
+		return KDF{}, ErrSeedNotFound
+	}
+	return kdf, err
+}
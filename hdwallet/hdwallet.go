@@ -1,60 +1,189 @@
 package hdwallet
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/howeyc/gopass"
-	"log"
+	"strings"
 	"wallet/hdkeystore"
+	"wallet/password"
+)
+
+var (
+	//助记词单词或校验和不合法
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	//keystore目录中已有其他助记词(或passphrase、口令不同)的种子文件
+	ErrSeedExists = errors.New("a different seed vault already exists")
+	//与hdkeystore中的错误相同, 便于调用方只引用本包
+	ErrAccountNotFound = hdkeystore.ErrAccountNotFound
+	ErrWrongPassword   = hdkeystore.ErrWrongPassword
 )
 
 type HDWallet struct {
 	Address common.Address
 	HDKeystore *hdkeystore.HDKeyStore
+	//私钥的推导路径, 通过keystore文件加载时为空
+	Path string
+	//BIP-39种子, 仅由助记词或种子文件创建时存在, 用于StoreKey时持久化
+	seed []byte
 }
 
-func NewHDWallet(keypath string) (*HDWallet, error) {
+//创建words个单词、lang语言助记词的新钱包, 同时返回助记词供调用方备份
+//passphrase即BIP-39的"第25个单词", 无则传空串
+func NewHDWallet(words int, lang, passphrase, keypath string) (*HDWallet, string, error) {
 	//1.创建助记词
-	mne, err := createMnemonic()
+	mne, err := NewMnemonic(words, lang)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create mnemonic: %w", err)
+	}
+	w, err := NewHDWalletFromMnemonic(mne, passphrase, defaultPath, keypath)
+	if err != nil {
+		return nil, "", err
+	}
+	return w, mne, nil
+}
+
+//通过已有助记词恢复钱包, 用于从纸质备份导入, path为空时使用默认路径
+func NewHDWalletFromMnemonic(mne, passphrase, path, keypath string) (*HDWallet, error) {
+	seed, err := newSeedChecked(mne, passphrase)
 	if err != nil {
-		fmt.Println("Failed to NewHDWallet: ", err)
 		return nil, err
 	}
-	//2. 推导私钥
-	privateKey, err := DerivePrivateKeyFromMnemonic(mne)
+	masterKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
 	if err != nil {
-		fmt.Println("Failed to DerivePrivateKeyFromMnemonic: ", err)
 		return nil, err
 	}
-	//3. 获取地址
-	publicKey, err := DerivePublicKey(privateKey)
+	return newHDWalletFromMasterKey(seed, masterKey, path, keypath)
+}
+
+//由同一助记词按BIP-44推导account账户下index从start开始的count个钱包
+func DeriveHDWallets(mne, passphrase string, account, start, count uint32, keypath string) ([]*HDWallet, error) {
+	seed, err := newSeedChecked(mne, passphrase)
 	if err != nil {
-		fmt.Println("Failed to DerivePublicKey: ", err)
 		return nil, err
 	}
+	//master key只计算一次
+	masterKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, err
+	}
+	wallets := make([]*HDWallet, 0, count)
+	for i := start; i < start+count; i++ {
+		w, err := newHDWalletFromMasterKey(seed, masterKey, BIP44Path(account, 0, i), keypath)
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, w)
+	}
+	return wallets, nil
+}
+
+//解密keystore目录中的种子文件, 推导并储存下一个账户, 新账户使用同一口令与密钥派生参数
+func DeriveNextAccount(keypath, pass string) (*HDWallet, error) {
+	//1. 解密种子
+	hdks := hdkeystore.NewHDKeyStoreWithoutKey(keypath)
+	seed, err := hdks.GetSeed(pass)
+	if err != nil {
+		return nil, err
+	}
+	//2. 按照种子文件记录的index推导
+	masterKey, err := hdkeychain.NewMaster(seed.Seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, err
+	}
+	w, err := newHDWalletFromMasterKey(seed.Seed, masterKey, BIP44Path(seed.Account, 0, seed.NextIndex), keypath)
+	if err != nil {
+		return nil, err
+	}
+	kdf, err := hdks.SeedKDF()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.HDKeystore.SetKDF(kdf); err != nil {
+		return nil, err
+	}
+	//3. 储存账户, 同时更新种子文件中的index
+	if err := w.StoreKey(pass); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func newHDWalletFromMasterKey(seed []byte, masterKey *hdkeychain.ExtendedKey, path, keypath string) (*HDWallet, error) {
+	if path == "" {
+		path = defaultPath
+	}
+	//1. 推导私钥
+	privateKey, err := DerivePrivateKeyFromPath(path, masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive private key from path %s: %w", path, err)
+	}
+	//2. 获取地址
+	publicKey, err := DerivePublicKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive public key: %w", err)
+	}
 	//通过公钥推导地址
 	address := crypto.PubkeyToAddress(*publicKey)
-	//4. 创建keystore
+	//3. 创建keystore
 	hdks := hdkeystore.NewHDKeyStore(keypath, privateKey)
-	//5. 创建钱包
+	//4. 创建钱包
 	return &HDWallet{
 		Address:    address,
 		HDKeystore: hdks,
+		Path:       path,
+		seed:       seed,
+	}, nil
+}
+
+//通过16进制私钥导入钱包, 可带0x前缀
+func NewHDWalletFromPrivateKey(hexkey, keypath string) (*HDWallet, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexkey), "0x"))
+	if err != nil {
+		return nil, err
+	}
+	return &HDWallet{
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+		HDKeystore: hdkeystore.NewHDKeyStore(keypath, privateKey),
 	}, nil
 }
 
-//通过账户文件来构建钱包文件，以用户输入非明文方式获得私钥
-func LoadWallet(filename, keypath string) (*HDWallet, error) {
+//通过其他客户端(geth、MyEtherWallet等)生成的V3 keystore文件导入钱包
+//auth为原文件口令, 之后通过StoreKey以本钱包的参数重新加密
+func NewHDWalletFromKeyJSON(keyjson []byte, auth, keypath string) (*HDWallet, error) {
+	key, err := keystore.DecryptKey(keyjson, auth)
+	if err == keystore.ErrDecrypt {
+		return nil, fmt.Errorf("%w for imported keystore file", ErrWrongPassword)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &HDWallet{
+		Address:    key.Address,
+		HDKeystore: hdkeystore.NewHDKeyStore(keypath, key.PrivateKey),
+	}, nil
+}
+
+//通过账户文件来构建钱包文件, 口令由passwords提供, 为nil时在终端提示输入
+func LoadWallet(filename, keypath string, passwords password.Provider) (*HDWallet, error) {
+	if passwords == nil {
+		passwords = password.TTY{}
+	}
 	//在无私钥时创建钱包
 	hdks := hdkeystore.NewHDKeyStoreWithoutKey(keypath)
-	fmt.Println("Please input password for: ", filename)
-	pass, _ := gopass.GetPasswd()
+	pass, err := passwords.Password(filename)
+	if err != nil {
+		return nil, err
+	}
 	//filename也是账户地址
 	fromaddr := common.HexToAddress(filename)
-	_, err := hdks.GetKey(fromaddr, hdks.JoinPath(filename), string(pass))
-	if err != nil {
-		log.Panic("Failed to GetKey: ", err)
+	if _, err := hdks.GetKey(fromaddr, hdks.JoinPath(filename), pass); err != nil {
+		return nil, err
 	}
 	return &HDWallet{
 		Address:    fromaddr,
@@ -63,7 +192,63 @@ func LoadWallet(filename, keypath string) (*HDWallet, error) {
 }
 
 func (hw *HDWallet) StoreKey(pass string) error {
+	//由助记词创建的钱包同时持久化种子, 写入账户文件之前先确认不会与已有的种子冲突
+	var seed *hdkeystore.Seed
+	var seedExists bool
+	if hw.seed != nil {
+		var err error
+		if seed, seedExists, err = hw.nextSeed(pass); err != nil {
+			return err
+		}
+	}
 	//账户即文件名
 	filename := hw.HDKeystore.JoinPath(hw.Address.Hex())
-	return hw.HDKeystore.StoreKey(filename, &hw.HDKeystore.Key, pass)
-}
\ No newline at end of file
+	if err := hw.HDKeystore.StoreKey(filename, &hw.HDKeystore.Key, pass); err != nil {
+		return err
+	}
+	switch {
+	case seed == nil:
+		return nil
+	case seedExists:
+		return hw.HDKeystore.UpdateSeed(seed, pass)
+	default:
+		return hw.HDKeystore.StoreSeed(seed, pass)
+	}
+}
+
+//推导并储存下一个账户, 见DeriveNextAccount
+func (hw *HDWallet) DeriveNextAccount(pass string) (*HDWallet, error) {
+	return DeriveNextAccount(hw.HDKeystore.KeyDirPath(), pass)
+}
+
+//待写入的种子: 种子文件不存在时返回新种子; 已存在且为同一种子时返回推进NextIndex后的种子, 无需推进时返回nil
+//已存在其他种子或口令不同时返回ErrSeedExists, 否则之后newaccount推导出的账户无法由本钱包的助记词恢复
+func (hw *HDWallet) nextSeed(pass string) (*hdkeystore.Seed, bool, error) {
+	account, index, ok := parseBIP44Path(hw.Path)
+	if !hw.HDKeystore.HasSeed() {
+		seed := &hdkeystore.Seed{
+			Seed:    hw.seed,
+			Address: hw.Address,
+			Account: account,
+		}
+		if ok {
+			seed.NextIndex = index + 1
+		}
+		return seed, false, nil
+	}
+	seed, err := hw.HDKeystore.GetSeed(pass)
+	if errors.Is(err, ErrWrongPassword) {
+		return nil, true, fmt.Errorf("%w in %s: the password does not match it", ErrSeedExists, hw.HDKeystore.KeyDirPath())
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	if !bytes.Equal(seed.Seed, hw.seed) {
+		return nil, true, fmt.Errorf("%w in %s, created from another mnemonic (first address %s)", ErrSeedExists, hw.HDKeystore.KeyDirPath(), seed.Address.Hex())
+	}
+	if !ok || account != seed.Account || index < seed.NextIndex {
+		return nil, true, nil
+	}
+	seed.NextIndex = index + 1
+	return seed, true, nil
+}
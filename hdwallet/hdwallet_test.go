@@ -0,0 +1,94 @@
+package hdwallet
+
+import (
+	"errors"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+const (
+	testMnemonicA = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	testMnemonicB = "legal winner thank year wave sausage worth useful legal winner thank yellow"
+)
+
+//同一目录中只能有一个种子, 其他助记词或口令不同时拒绝储存, 且不写入账户文件
+func TestStoreKeyRefusesDifferentSeed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hdwallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wa, err := NewHDWalletFromMnemonic(testMnemonicA, "", "", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wa.StoreKey("pw"); err != nil {
+		t.Fatal(err)
+	}
+
+	wb, err := NewHDWalletFromMnemonic(testMnemonicB, "", "", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wb.StoreKey("pw"); !errors.Is(err, ErrSeedExists) {
+		t.Fatalf("StoreKey with another mnemonic = %v, want ErrSeedExists", err)
+	}
+	if _, err := os.Stat(wb.HDKeystore.JoinPath(wb.Address.Hex())); !os.IsNotExist(err) {
+		t.Fatal("account of the rejected mnemonic was stored")
+	}
+	//passphrase不同即为不同的种子
+	wc, err := NewHDWalletFromMnemonic(testMnemonicA, "TREZOR", "", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wc.StoreKey("pw"); !errors.Is(err, ErrSeedExists) {
+		t.Fatalf("StoreKey with another passphrase = %v, want ErrSeedExists", err)
+	}
+	wd, err := NewHDWalletFromMnemonic(testMnemonicA, "", BIP44Path(0, 0, 5), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.StoreKey("other"); !errors.Is(err, ErrSeedExists) {
+		t.Fatalf("StoreKey with another password = %v, want ErrSeedExists", err)
+	}
+
+	//同一种子推进index, 之后推导的账户仍来自助记词A
+	if err := wd.StoreKey("pw"); err != nil {
+		t.Fatal(err)
+	}
+	next, err := DeriveNextAccount(dir, "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := DeriveAddressFromMnemonic(testMnemonicA, "", BIP44Path(0, 0, 6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Address.Hex() != want {
+		t.Fatalf("DeriveNextAccount = %s, want %s", next.Address.Hex(), want)
+	}
+}
+
+//口令错误时返回ErrWrongPassword, 与读取keystore目录中的账户一致
+func TestNewHDWalletFromKeyJSONWrongPassword(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &keystore.Key{Id: uuid.New(), Address: crypto.PubkeyToAddress(privateKey.PublicKey), PrivateKey: privateKey}
+	keyjson, err := keystore.EncryptKey(key, "pw", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewHDWalletFromKeyJSON(keyjson, "wrong", ""); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("NewHDWalletFromKeyJSON = %v, want ErrWrongPassword", err)
+	}
+	w, err := NewHDWalletFromKeyJSON(keyjson, "pw", "")
+	if err != nil || w.Address != key.Address {
+		t.Fatalf("NewHDWalletFromKeyJSON = %v, %v", w, err)
+	}
+}
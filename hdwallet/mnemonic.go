@@ -9,81 +9,188 @@ import (
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/tyler-smith/go-bip39"
-	"log"
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/text/unicode/norm"
+	"sort"
+	"strings"
+	"sync"
 )
 
-func createMnemonic() (string, error){
-	entropy, err := bip39.NewEntropy(128)
-	if err != nil {
-		log.Panic("Failed to NewEntropy: ", err, entropy)
+//默认助记词单词数与语言
+const (
+	DefaultMnemonicWords    = 12
+	DefaultMnemonicLanguage = "english"
+)
+
+//go-bip39支持的单词表
+var wordLists = map[string][]string{
+	"english":             wordlists.English,
+	"chinese_simplified":  wordlists.ChineseSimplified,
+	"chinese_traditional": wordlists.ChineseTraditional,
+	"czech":               wordlists.Czech,
+	"french":              wordlists.French,
+	"italian":             wordlists.Italian,
+	"japanese":            wordlists.Japanese,
+	"korean":              wordlists.Korean,
+	"spanish":             wordlists.Spanish,
+}
+
+//bip39的单词表是包级全局变量, 切换时需要加锁
+var wordListMu sync.Mutex
+
+//支持的助记词语言, 按字母排序
+func MnemonicLanguages() []string {
+	langs := make([]string, 0, len(wordLists))
+	for lang := range wordLists {
+		langs = append(langs, lang)
 	}
-	//生成助记词
-	mne, err := bip39.NewMnemonic(entropy)
-	if err != nil {
-		log.Panic("Failed to NewMnemonic: ", err)
+	sort.Strings(langs)
+	return langs
+}
+
+//单词数转换为熵的位数: 12/15/18/21/24 -> 128/160/192/224/256
+func mnemonicEntropyBits(words int) (int, error) {
+	if words%3 != 0 || words < 12 || words > 24 {
+		return 0, fmt.Errorf("unsupported mnemonic length %d, want 12, 15, 18, 21 or 24 words", words)
 	}
-	fmt.Println(mne)
-	return mne, nil
+	return words / 3 * 32, nil
 }
 
-const defaultPath = "m/44'/60'/0'/0/1"
+//在指定单词表下执行fn
+func withWordList(lang string, fn func()) error {
+	list, ok := wordLists[lang]
+	if !ok {
+		return fmt.Errorf("unsupported mnemonic language %q", lang)
+	}
+	wordListMu.Lock()
+	defer wordListMu.Unlock()
+	bip39.SetWordList(list)
+	defer bip39.SetWordList(wordlists.English)
+	fn()
+	return nil
+}
 
-func DeriveAddressFromMnemonic(mne string) string {
-	//1. 推导目录
-	path, err := accounts.ParseDerivationPath(defaultPath)
+//生成words个单词的lang语言助记词
+func NewMnemonic(words int, lang string) (string, error) {
+	bits, err := mnemonicEntropyBits(words)
 	if err != nil {
-		log.Panic("Failed to ParseDerivationPath: ", err)
+		return "", err
 	}
-
-	//2. 通过助记词生成种子
-	seed, err := bip39.NewSeedWithErrorChecking(mne, "")
+	entropy, err := bip39.NewEntropy(bits)
 	if err != nil {
-		log.Panic("Failed to NewSeedWithErrorChecking: ", err)
+		return "", fmt.Errorf("failed to generate entropy: %w", err)
 	}
-
-	//3. 通过seed获取master key
-	masterKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	//生成助记词
+	var mne string
+	err = withWordList(lang, func() {
+		mne, err = bip39.NewMnemonic(entropy)
+	})
 	if err != nil {
-		log.Panic("Failed to NewMaster: ", err)
+		return "", err
+	}
+	//日文助记词按BIP-39约定以全角空格分隔
+	if lang == "japanese" {
+		mne = strings.Join(strings.Fields(mne), "\u3000")
 	}
+	return mne, nil
+}
 
-	//4. 推导私钥
-	privateKey, err := DerivePrivateKey(path, masterKey)
+//识别助记词所用单词表并校验checksum, 均不匹配时返回false
+func mnemonicLanguage(mne string) (string, bool) {
+	//优先尝试英文
+	langs := append([]string{DefaultMnemonicLanguage}, MnemonicLanguages()...)
+	for _, lang := range langs {
+		valid := false
+		_ = withWordList(lang, func() {
+			valid = bip39.IsMnemonicValid(mne)
+		})
+		if valid {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+//默认推导路径, 兼容已生成的钱包
+const defaultPath = "m/44'/60'/0'/0/1"
+
+//按照BIP-44规则拼接以太坊推导路径 m/44'/60'/account'/change/index
+func BIP44Path(account, change, index uint32) string {
+	return fmt.Sprintf("m/44'/60'/%d'/%d/%d", account, change, index)
+}
+
+//passphrase即BIP-39的"第25个单词", 无则传空串
+func DeriveAddressFromMnemonic(mne, passphrase, path string) (string, error) {
+	//1. 推导私钥
+	privateKey, err := DerivePrivateKeyFromMnemonic(mne, passphrase, path)
 	if err != nil {
-		log.Panic("Failed to DerivePrivateKey: ", err)
+		return "", err
 	}
-	//5. 推导公钥
+	//2. 推导公钥
 	publicKey, err := DerivePublicKey(privateKey)
 	if err != nil {
-		log.Panic("Failed to DerivePublicKey: ", err)
+		return "", err
 	}
-	//6. 利用公钥推导地址
+	//3. 利用公钥推导地址
 	address := crypto.PubkeyToAddress(*publicKey)
-	return address.Hex()
+	return address.Hex(), nil
 }
 
-//通过助记词推导私钥
-func DerivePrivateKeyFromMnemonic(mne string) (*ecdsa.PrivateKey, error) {
-	//1. 推导目录
-	path, err := accounts.ParseDerivationPath(defaultPath)
-	if err != nil {
-		log.Panic("Failed to ParseDerivationPath: ", err)
+//解析m/44'/60'/account'/change/index形式的路径, change不为0时返回false
+func parseBIP44Path(path string) (account, index uint32, ok bool) {
+	dpath, err := accounts.ParseDerivationPath(path)
+	if err != nil || len(dpath) != 5 {
+		return 0, 0, false
+	}
+	const hardened = hdkeychain.HardenedKeyStart
+	if dpath[0] != hardened+44 || dpath[1] != hardened+60 || dpath[2] < hardened || dpath[3] != 0 || dpath[4] >= hardened {
+		return 0, 0, false
 	}
+	return dpath[2] - hardened, dpath[4], true
+}
 
-	//2. 通过助记词生成种子
-	seed, err := bip39.NewSeedWithErrorChecking(mne, "")
+//通过助记词推导私钥, path为空时使用默认路径
+func DerivePrivateKeyFromMnemonic(mne, passphrase, path string) (*ecdsa.PrivateKey, error) {
+	//1. 通过助记词获取master key
+	masterKey, err := NewMasterKey(mne, passphrase)
 	if err != nil {
-		log.Panic("Failed to NewSeedWithErrorChecking: ", err)
+		return nil, err
 	}
+	//2. 推导私钥
+	return DerivePrivateKeyFromPath(path, masterKey)
+}
 
-	//3. 通过seed获取master key
-	masterKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+//通过助记词与passphrase生成种子, 再由种子获取master key
+func NewMasterKey(mne, passphrase string) (*hdkeychain.ExtendedKey, error) {
+	//1. 通过助记词生成种子
+	seed, err := newSeedChecked(mne, passphrase)
 	if err != nil {
-		log.Panic("Failed to NewMaster: ", err)
+		return nil, err
+	}
+	//2. 通过seed获取master key
+	return hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+}
+
+//校验助记词(单词表与checksum), 然后结合passphrase生成种子
+//按BIP-39要求对助记词与passphrase做NFKD规范化, 与硬件钱包等实现保持一致
+func newSeedChecked(mne, passphrase string) ([]byte, error) {
+	mne = strings.Join(strings.Fields(norm.NFKD.String(mne)), " ")
+	if _, ok := mnemonicLanguage(mne); !ok {
+		return nil, ErrInvalidMnemonic
 	}
+	return bip39.NewSeed(mne, norm.NFKD.String(passphrase)), nil
+}
 
-	//4. 推导私钥
-	return DerivePrivateKey(path, masterKey)
+//解析字符串路径并推导私钥, path为空时使用默认路径
+func DerivePrivateKeyFromPath(path string, masterKey *hdkeychain.ExtendedKey) (*ecdsa.PrivateKey, error) {
+	if path == "" {
+		path = defaultPath
+	}
+	dpath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return DerivePrivateKey(dpath, masterKey)
 }
 
 //推导私钥
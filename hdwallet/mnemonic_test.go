@@ -0,0 +1,142 @@
+package hdwallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+//BIP-39官方测试向量(英文以TREZOR为passphrase)与日文向量, 日文向量同时检验NFKD规范化
+func TestSeedVectors(t *testing.T) {
+	tests := []struct {
+		mnemonic, passphrase, seed, xprv string
+	}{
+		{
+			testMnemonicA, "TREZOR",
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+			"xprv9s21ZrQH143K3h3fDYiay8mocZ3afhfULfb5GX8kCBdno77K4HiA15Tg23wpbeF1pLfs1c5SPmYHrEpTuuRhxMwvKDwqdKiGJS9XFKzUsAF",
+		},
+		{
+			testMnemonicB, "TREZOR",
+			"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+			"",
+		},
+		{
+			"あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あおぞら",
+			"㍍ガバヴァぱばぐゞちぢ十人十色",
+			"a262d6fb6122ecf45be09c50492b31f92e9beb7d9a845987a02cefda57a15f9c467a17872029a9e92299b5cbdf306e3a0ee620245cbd508959b6cb7ca637bd55",
+			"",
+		},
+	}
+	for _, tt := range tests {
+		seed, err := newSeedChecked(tt.mnemonic, tt.passphrase)
+		if err != nil {
+			t.Fatalf("newSeedChecked(%q): %v", tt.mnemonic, err)
+		}
+		if got := hex.EncodeToString(seed); got != tt.seed {
+			t.Errorf("seed of %q = %s, want %s", tt.mnemonic, got, tt.seed)
+		}
+		if tt.xprv == "" {
+			continue
+		}
+		masterKey, err := NewMasterKey(tt.mnemonic, tt.passphrase)
+		if err != nil || masterKey.String() != tt.xprv {
+			t.Errorf("master key of %q = %v, %v, want %s", tt.mnemonic, masterKey, err, tt.xprv)
+		}
+	}
+}
+
+//BIP-44以太坊地址, 与MetaMask、Ledger等对同一助记词推导的地址一致
+func TestDeriveAddressVectors(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{BIP44Path(0, 0, 0), "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"},
+		{BIP44Path(0, 0, 1), "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"},
+		{BIP44Path(0, 0, 2), "0xb6716976A3ebe8D39aCEB04372f22Ff8e6802D7A"},
+		{"", "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"},
+	}
+	for _, tt := range tests {
+		got, err := DeriveAddressFromMnemonic(testMnemonicA, "", tt.path)
+		if err != nil || got != tt.want {
+			t.Errorf("DeriveAddressFromMnemonic(%q) = %s, %v, want %s", tt.path, got, err, tt.want)
+		}
+	}
+	//passphrase不同推导出不同的地址
+	got, err := DeriveAddressFromMnemonic(testMnemonicA, "TREZOR", BIP44Path(0, 0, 0))
+	if err != nil || got == tests[0].want {
+		t.Fatalf("address with passphrase = %s, %v, want a different address", got, err)
+	}
+}
+
+//导入助记词: 校验checksum, 多余空白不影响结果
+func TestNewHDWalletFromMnemonic(t *testing.T) {
+	w, err := NewHDWalletFromMnemonic("  "+strings.Replace(testMnemonicA, " ", "  ", 3)+"\n", "", BIP44Path(0, 0, 0), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Address.Hex() != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" || w.Path != BIP44Path(0, 0, 0) {
+		t.Fatalf("wallet = %s %s", w.Address.Hex(), w.Path)
+	}
+	//最后一个单词改变后checksum不匹配
+	bad := strings.Replace(testMnemonicA, "about", "abandon", 1)
+	if _, err := NewHDWalletFromMnemonic(bad, "", "", ""); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatalf("NewHDWalletFromMnemonic with bad checksum = %v, want ErrInvalidMnemonic", err)
+	}
+	if _, err := NewHDWalletFromMnemonic(testMnemonicA, "", "m/44'/60'/x", ""); err == nil {
+		t.Fatal("NewHDWalletFromMnemonic accepted an invalid path")
+	}
+}
+
+func TestDeriveHDWallets(t *testing.T) {
+	wallets, err := DeriveHDWallets(testMnemonicA, "", 0, 1, 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0", "0xb6716976A3ebe8D39aCEB04372f22Ff8e6802D7A"}
+	if len(wallets) != len(want) {
+		t.Fatalf("got %d wallets, want %d", len(wallets), len(want))
+	}
+	for i, w := range wallets {
+		if w.Address.Hex() != want[i] || w.Path != BIP44Path(0, 0, uint32(i+1)) {
+			t.Errorf("wallet %d = %s %s, want %s", i, w.Address.Hex(), w.Path, want[i])
+		}
+	}
+	//不同account下的地址与account 0不同
+	other, err := DeriveHDWallets(testMnemonicA, "", 1, 1, 1, "")
+	if err != nil || other[0].Address == wallets[0].Address || other[0].Path != BIP44Path(1, 0, 1) {
+		t.Fatalf("account 1 wallet = %v, %v", other, err)
+	}
+}
+
+//各单词数与语言的新助记词均可通过校验并导入
+func TestNewMnemonic(t *testing.T) {
+	for _, lang := range MnemonicLanguages() {
+		for _, words := range []int{12, 15, 18, 21, 24} {
+			mne, err := NewMnemonic(words, lang)
+			if err != nil {
+				t.Fatalf("NewMnemonic(%d, %s): %v", words, lang, err)
+			}
+			if n := len(strings.Fields(mne)); n != words {
+				t.Fatalf("NewMnemonic(%d, %s) has %d words", words, lang, n)
+			}
+			if _, err := NewHDWalletFromMnemonic(mne, "", "", ""); err != nil {
+				t.Fatalf("NewHDWalletFromMnemonic(%s mnemonic): %v", lang, err)
+			}
+		}
+	}
+	//日文助记词以全角空格分隔
+	mne, err := NewMnemonic(12, "japanese")
+	if err != nil || !strings.Contains(mne, "　") {
+		t.Fatalf("japanese mnemonic = %q, %v", mne, err)
+	}
+	for _, words := range []int{0, 11, 13, 27} {
+		if _, err := NewMnemonic(words, DefaultMnemonicLanguage); err == nil {
+			t.Errorf("NewMnemonic(%d) succeeded", words)
+		}
+	}
+	if _, err := NewMnemonic(12, "klingon"); err == nil {
+		t.Error("NewMnemonic accepted an unknown language")
+	}
+}
@@ -0,0 +1,273 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+//leveldb数据库目录名, 保存已发送的交易与同步到的代币事件
+const DirName = "history"
+
+//交易状态
+const (
+	StatusPending  = "pending"
+	StatusMined    = "mined"
+	StatusFailed   = "failed"
+	StatusReplaced = "replaced"
+)
+
+//代币事件的topic
+var (
+	TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	ApprovalTopic = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+)
+
+//数据库中的键, 同一数据目录可能用于多个网络, 均以链ID区分
+//tx-<链ID>-<交易哈希>: 本钱包发送的交易
+//log-<链ID>-<代币地址>-<区块号>-<日志序号>: 代币事件, 区块号与序号定长编码, 按键遍历即按时间排序
+//sync-<链ID>-<代币地址>-<账户地址>: 该账户已同步到的区块
+//chain-<节点地址>: 节点的链ID, 离线时用于确定网络
+func txPrefix(chainID uint64) []byte {
+	return []byte(fmt.Sprintf("tx-%d-", chainID))
+}
+
+func logPrefix(chainID uint64, token common.Address) []byte {
+	return []byte(fmt.Sprintf("log-%d-%s-", chainID, token.Hex()))
+}
+
+func eventKey(chainID uint64, e *Event) []byte {
+	return append(logPrefix(chainID, e.Token), fmt.Sprintf("%016x-%08x", e.Block, e.Index)...)
+}
+
+func syncKey(chainID uint64, token, account common.Address) []byte {
+	return []byte(fmt.Sprintf("sync-%d-%s-%s", chainID, token.Hex(), account.Hex()))
+}
+
+func chainKey(rpcURL string) []byte {
+	return []byte("chain-" + rpcURL)
+}
+
+//本钱包发送的交易
+type Tx struct {
+	Hash    common.Hash     `json:"hash"`
+	ChainID uint64          `json:"chainId"`
+	From    common.Address  `json:"from"`
+	//部署合约时为空
+	To    *common.Address `json:"to,omitempty"`
+	Nonce uint64          `json:"nonce"`
+	Value *big.Int        `json:"value"`
+	//调用的合约方法, 普通转账为空
+	Method string `json:"method,omitempty"`
+	//发送时间, unix秒
+	Time   int64  `json:"time"`
+	Status string `json:"status"`
+	//上链区块, 未上链时为0
+	Block uint64 `json:"block,omitempty"`
+}
+
+//代币的Transfer或Approval事件, Approval的From与To分别为owner与spender
+type Event struct {
+	Name   string         `json:"name"`
+	Token  common.Address `json:"token"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Value  *big.Int       `json:"value"`
+	Block  uint64         `json:"block"`
+	TxHash common.Hash    `json:"txHash"`
+	Index  uint           `json:"index"`
+}
+
+//解析Transfer或Approval日志, 其他日志返回false
+func ParseLog(l types.Log) (*Event, bool) {
+	if len(l.Topics) != 3 {
+		return nil, false
+	}
+	var name string
+	switch l.Topics[0] {
+	case TransferTopic:
+		name = "Transfer"
+	case ApprovalTopic:
+		name = "Approval"
+	default:
+		return nil, false
+	}
+	return &Event{
+		Name:   name,
+		Token:  l.Address,
+		From:   common.BytesToAddress(l.Topics[1].Bytes()),
+		To:     common.BytesToAddress(l.Topics[2].Bytes()),
+		Value:  new(big.Int).SetBytes(l.Data),
+		Block:  l.BlockNumber,
+		TxHash: l.TxHash,
+		Index:  l.Index,
+	}, true
+}
+
+//本地历史数据库, 基于leveldb, 同一时间只能被一个进程打开
+type DB struct {
+	db *leveldb.Database
+}
+
+//打开数据目录下的历史数据库, 不存在时创建
+func Open(dataDir string) (*DB, error) {
+	db, err := leveldb.New(filepath.Join(dataDir, DirName), 16, 16, "wallet/history/", false)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{db: db}, nil
+}
+
+func (db *DB) Close() error {
+	return db.db.Close()
+}
+
+//记录发送的交易, 同一地址同一nonce的未上链交易视为被替换
+func (db *DB) PutTx(tx *Tx) error {
+	txs, err := db.Txs(tx.ChainID, tx.From)
+	if err != nil {
+		return err
+	}
+	batch := db.db.NewBatch()
+	for _, old := range txs {
+		if old.Nonce == tx.Nonce && old.Hash != tx.Hash && old.Status == StatusPending {
+			old.Status = StatusReplaced
+			content, err := json.Marshal(old)
+			if err != nil {
+				return err
+			}
+			if err := batch.Put(append(txPrefix(old.ChainID), old.Hash.Hex()...), content); err != nil {
+				return err
+			}
+		}
+	}
+	content, err := json.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	if err := batch.Put(append(txPrefix(tx.ChainID), tx.Hash.Hex()...), content); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+//链上from发送的交易, 按nonce排序, from为零地址时返回所有交易
+func (db *DB) Txs(chainID uint64, from common.Address) ([]*Tx, error) {
+	it := db.db.NewIterator(txPrefix(chainID), nil)
+	defer it.Release()
+	var txs []*Tx
+	for it.Next() {
+		tx := new(Tx)
+		if err := json.Unmarshal(it.Value(), tx); err != nil {
+			return nil, err
+		}
+		if from == (common.Address{}) || tx.From == from {
+			txs = append(txs, tx)
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(txs, func(i, j int) bool {
+		if txs[i].Nonce != txs[j].Nonce {
+			return txs[i].Nonce < txs[j].Nonce
+		}
+		return txs[i].Time < txs[j].Time
+	})
+	return txs, nil
+}
+
+//以重新查询到的事件替换account在[from, to]区块内的事件, 删除因链重组不再存在的事件
+func (db *DB) ReplaceEvents(chainID uint64, token, account common.Address, from, to uint64, events []*Event) error {
+	prefix := logPrefix(chainID, token)
+	it := db.db.NewIterator(prefix, []byte(fmt.Sprintf("%016x", from)))
+	defer it.Release()
+	batch := db.db.NewBatch()
+	for it.Next() {
+		e := new(Event)
+		if err := json.Unmarshal(it.Value(), e); err != nil {
+			return err
+		}
+		if e.Block > to {
+			break
+		}
+		if e.From == account || e.To == account {
+			if err := batch.Delete(common.CopyBytes(it.Key())); err != nil {
+				return err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	for _, e := range events {
+		content, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(eventKey(chainID, e), content); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+//代币token中与account相关的事件, 按区块与日志序号排序
+func (db *DB) Events(chainID uint64, token, account common.Address) ([]*Event, error) {
+	it := db.db.NewIterator(logPrefix(chainID, token), nil)
+	defer it.Release()
+	var events []*Event
+	for it.Next() {
+		e := new(Event)
+		if err := json.Unmarshal(it.Value(), e); err != nil {
+			return nil, err
+		}
+		if e.From == account || e.To == account {
+			events = append(events, e)
+		}
+	}
+	return events, it.Error()
+}
+
+//account在代币token上已同步到的区块, 从未同步时返回false
+func (db *DB) Synced(chainID uint64, token, account common.Address) (uint64, bool, error) {
+	return db.getUint(syncKey(chainID, token, account))
+}
+
+func (db *DB) SetSynced(chainID uint64, token, account common.Address, block uint64) error {
+	return db.db.Put(syncKey(chainID, token, account), []byte(strconv.FormatUint(block, 10)))
+}
+
+//记录节点的链ID
+func (db *DB) SetChainID(rpcURL string, chainID uint64) error {
+	return db.db.Put(chainKey(rpcURL), []byte(strconv.FormatUint(chainID, 10)))
+}
+
+//离线时按节点地址查找上次记录的链ID, 未记录时返回false
+func (db *DB) ChainID(rpcURL string) (uint64, bool, error) {
+	return db.getUint(chainKey(rpcURL))
+}
+
+//读取十进制编码的整数, 键不存在时返回false
+func (db *DB) getUint(key []byte) (uint64, bool, error) {
+	has, err := db.db.Has(key)
+	if err != nil || !has {
+		return 0, false, err
+	}
+	content, err := db.db.Get(key)
+	if err != nil {
+		return 0, false, err
+	}
+	n, err := strconv.ParseUint(string(content), 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return n, true, nil
+}
@@ -0,0 +1,55 @@
+package history
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+)
+
+//重新扫描的区块范围内, 被重组移除的事件删除, 范围外与其他账户的事件保留
+func TestReplaceEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	token := common.HexToAddress("0x967b24fe559fCaAA57d5F3Fa033517fdaA005bD6")
+	acct := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	other := common.HexToAddress("0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0")
+	third := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	event := func(block uint64, index uint, from, to common.Address) *Event {
+		return &Event{Name: "Transfer", Token: token, From: from, To: to, Value: big.NewInt(1), Block: block, Index: index}
+	}
+	initial := []*Event{event(10, 0, acct, other), event(20, 0, other, acct), event(20, 1, other, third), event(30, 0, acct, other)}
+	if err := db.ReplaceEvents(1, token, acct, 0, 30, initial); err != nil {
+		t.Fatal(err)
+	}
+	//区块20被重组, 其中的事件移到区块21
+	if err := db.ReplaceEvents(1, token, acct, 15, 25, []*Event{event(21, 0, other, acct)}); err != nil {
+		t.Fatal(err)
+	}
+	events, err := db.Events(1, token, acct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blocks []uint64
+	for _, e := range events {
+		blocks = append(blocks, e.Block)
+	}
+	if len(blocks) != 3 || blocks[0] != 10 || blocks[1] != 21 || blocks[2] != 30 {
+		t.Fatalf("event blocks = %v, want [10 21 30]", blocks)
+	}
+	//与acct无关的事件不受影响
+	events, err = db.Events(1, token, third)
+	if err != nil || len(events) != 1 || events[0].Block != 20 {
+		t.Fatalf("events of third = %v, %v", events, err)
+	}
+}
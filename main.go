@@ -1,6 +1,13 @@
 package main
 
-import "wallet/cli"
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"wallet/cli"
+	"wallet/config"
+)
 
 func main() {
 	//w, err := hdwallet.NewHDWallet("./keystore")
@@ -10,6 +17,14 @@ func main() {
 	//}
 	//w.StoreKey("123")
 
-	c := cli.NewCmdClient("http://localhost:8545", "./keystore")
-	c.Run()
-}
\ No newline at end of file
+	c := cli.NewCmdClient(config.DefaultRPC, config.DefaultDataDir)
+	err := c.Run()
+	//-h/-help时flag包已输出用法, 正常退出
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: ", err)
+		os.Exit(1)
+	}
+}
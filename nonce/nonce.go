@@ -0,0 +1,184 @@
+package nonce
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"wallet/hdkeystore"
+)
+
+//按地址记录下一个nonce与未确认交易的JSON文件, 连续运行的多次命令据此分配nonce
+const FileName = "nonces.json"
+
+//查询节点nonce与交易所需的接口, ethclient.Client已实现
+type Backend interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+}
+
+//本地记录的已发送但尚未确认的交易
+type PendingTx struct {
+	Nonce uint64      `json:"nonce"`
+	Hash  common.Hash `json:"hash"`
+}
+
+//单个地址的nonce状态
+type accountState struct {
+	//下一个可用nonce
+	Next uint64 `json:"next"`
+	//已发送未确认的交易
+	Pending []PendingTx `json:"pending"`
+}
+
+//nonce管理器, 跨进程持久化每个地址的下一个nonce, 并与节点的pending nonce对账
+type Manager struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewManager(dataDir string) *Manager {
+	return &Manager{
+		path: filepath.Join(dataDir, FileName),
+	}
+}
+
+//获取addr下一个可用的nonce, 同时返回因被节点丢弃而产生空洞的交易
+//1. 节点已确认的nonce之前的本地记录视为已上链, 予以清除
+//2. 节点pending nonce更大时(例如其他工具发送了交易)以节点为准
+//3. 本地nonce更大时逐笔向节点查询[pending, 本地)区间的交易: 节点仍知道的交易保留(可能只是尚未进入节点的pending计数),
+//节点查不到的交易视为已被丢弃; 从第一个节点不知道的nonce开始, 没有空洞时即本地nonce
+func (m *Manager) Next(ctx context.Context, backend Backend, addr common.Address) (uint64, []PendingTx, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pending, err := backend.PendingNonceAt(ctx, addr)
+	if err != nil {
+		return 0, nil, err
+	}
+	confirmed, err := backend.NonceAt(ctx, addr, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	states, err := m.load()
+	if err != nil {
+		return 0, nil, err
+	}
+	state := states[addr.Hex()]
+	//1. 清除已上链的记录
+	kept := state.Pending[:0]
+	for _, tx := range state.Pending {
+		if tx.Nonce >= confirmed {
+			kept = append(kept, tx)
+		}
+	}
+	state.Pending = kept
+	//2. 与节点对账
+	var dropped []PendingTx
+	switch {
+	case state.Next < pending:
+		state.Next = pending
+	case state.Next > pending:
+		//3. 检测空洞
+		known := make(map[uint64]bool)
+		kept = state.Pending[:0]
+		for _, tx := range state.Pending {
+			if tx.Nonce >= pending {
+				_, _, err := backend.TransactionByHash(ctx, tx.Hash)
+				if err == ethereum.NotFound {
+					dropped = append(dropped, tx)
+					continue
+				}
+				if err != nil {
+					return 0, nil, err
+				}
+				known[tx.Nonce] = true
+			}
+			kept = append(kept, tx)
+		}
+		state.Pending = kept
+		//跳过节点已知的连续nonce; 填补空洞后, 空洞之后仍被节点保留的交易也需跳过
+		n := pending
+		for known[n] {
+			n++
+		}
+		state.Next = n
+	}
+	states[addr.Hex()] = state
+	if err := m.save(states); err != nil {
+		return 0, nil, err
+	}
+	return state.Next, dropped, nil
+}
+
+//交易发送成功后记录, 推进addr的下一个nonce
+func (m *Manager) Commit(addr common.Address, nonce uint64, hash common.Hash) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	states, err := m.load()
+	if err != nil {
+		return err
+	}
+	state := states[addr.Hex()]
+	//同一nonce的替换交易覆盖原记录
+	replaced := false
+	for i := range state.Pending {
+		if state.Pending[i].Nonce == nonce {
+			state.Pending[i].Hash = hash
+			replaced = true
+		}
+	}
+	if !replaced {
+		state.Pending = append(state.Pending, PendingTx{Nonce: nonce, Hash: hash})
+		sort.Slice(state.Pending, func(i, j int) bool {
+			return state.Pending[i].Nonce < state.Pending[j].Nonce
+		})
+	}
+	if nonce+1 > state.Next {
+		state.Next = nonce + 1
+	}
+	states[addr.Hex()] = state
+	return m.save(states)
+}
+
+//本地记录的addr未确认交易
+func (m *Manager) Pending(addr common.Address) ([]PendingTx, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	states, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	return states[addr.Hex()].Pending, nil
+}
+
+func (m *Manager) load() (map[string]accountState, error) {
+	states := make(map[string]accountState)
+	content, err := ioutil.ReadFile(m.path)
+	if os.IsNotExist(err) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+//复用keystore的原子写, 避免中断时损坏记录
+func (m *Manager) save(states map[string]accountState) error {
+	content, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	return hdkeystore.WriteKeyFile(m.path, content)
+}
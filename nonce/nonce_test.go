@@ -0,0 +1,168 @@
+package nonce
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+)
+
+//模拟节点: pending与confirmed为节点返回的nonce, known为节点交易池或链上能查到的交易
+type fakeBackend struct {
+	pending   uint64
+	confirmed uint64
+	known     map[common.Hash]bool
+	err       error
+}
+
+func (b *fakeBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return b.pending, nil
+}
+
+func (b *fakeBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return b.confirmed, nil
+}
+
+func (b *fakeBackend) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	if b.err != nil {
+		return nil, false, b.err
+	}
+	if !b.known[hash] {
+		return nil, false, ethereum.NotFound
+	}
+	return new(types.Transaction), true, nil
+}
+
+var testAddr = common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+
+func hashOf(n byte) common.Hash {
+	return common.BytesToHash([]byte{n})
+}
+
+//依次提交nonce
+func commit(t *testing.T, m *Manager, nonces ...uint64) {
+	for _, n := range nonces {
+		if err := m.Commit(testAddr, n, hashOf(byte(n))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNextFreshAccountUsesNode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m := NewManager(dir)
+	next, dropped, err := m.Next(context.Background(), &fakeBackend{pending: 7, confirmed: 7}, testAddr)
+	if err != nil || next != 7 || len(dropped) != 0 {
+		t.Fatalf("Next = %d, %v, %v, want 7", next, dropped, err)
+	}
+}
+
+//连续发送: 节点已收到交易但pending nonce尚未更新时, 本地nonce优先
+func TestNextKeepsLocalNonceForKnownTx(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m := NewManager(dir)
+	commit(t, m, 3)
+	backend := &fakeBackend{pending: 3, confirmed: 3, known: map[common.Hash]bool{hashOf(3): true}}
+	next, dropped, err := m.Next(context.Background(), backend, testAddr)
+	if err != nil || next != 4 || len(dropped) != 0 {
+		t.Fatalf("Next = %d, %v, %v, want 4 and nothing dropped", next, dropped, err)
+	}
+}
+
+func TestNextReusesDroppedNonce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m := NewManager(dir)
+	commit(t, m, 3)
+	next, dropped, err := m.Next(context.Background(), &fakeBackend{pending: 3, confirmed: 3}, testAddr)
+	if err != nil || next != 3 {
+		t.Fatalf("Next = %d, %v, want 3", next, err)
+	}
+	if len(dropped) != 1 || dropped[0].Nonce != 3 {
+		t.Fatalf("dropped = %v, want nonce 3", dropped)
+	}
+	pending, err := m.Pending(testAddr)
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("Pending = %v, %v, want none", pending, err)
+	}
+}
+
+//中间的交易被丢弃时从空洞处填补, 之后的交易仍保留
+func TestNextFillsHole(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m := NewManager(dir)
+	commit(t, m, 3, 4, 5)
+	backend := &fakeBackend{pending: 4, confirmed: 3, known: map[common.Hash]bool{hashOf(3): true, hashOf(5): true}}
+	next, dropped, err := m.Next(context.Background(), backend, testAddr)
+	if err != nil || next != 4 {
+		t.Fatalf("Next = %d, %v, want 4", next, err)
+	}
+	if len(dropped) != 1 || dropped[0].Nonce != 4 {
+		t.Fatalf("dropped = %v, want nonce 4", dropped)
+	}
+	//填补空洞后跳过仍在节点中的nonce 5
+	commit(t, m, 4)
+	backend.known[hashOf(4)] = true
+	next, dropped, err = m.Next(context.Background(), backend, testAddr)
+	if err != nil || next != 6 || len(dropped) != 0 {
+		t.Fatalf("Next = %d, %v, %v, want 6", next, dropped, err)
+	}
+}
+
+func TestNextFollowsNodeAhead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m := NewManager(dir)
+	commit(t, m, 3)
+	next, _, err := m.Next(context.Background(), &fakeBackend{pending: 10, confirmed: 9}, testAddr)
+	if err != nil || next != 10 {
+		t.Fatalf("Next = %d, %v, want 10", next, err)
+	}
+	pending, err := m.Pending(testAddr)
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("Pending = %v, %v, want confirmed tx cleared", pending, err)
+	}
+}
+
+func TestNextReturnsLookupError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m := NewManager(dir)
+	commit(t, m, 3)
+	lookupErr := errors.New("connection reset")
+	_, _, err = m.Next(context.Background(), &fakeBackend{pending: 3, confirmed: 3, err: lookupErr}, testAddr)
+	if !errors.Is(err, lookupErr) {
+		t.Fatalf("Next error = %v, want %v", err, lookupErr)
+	}
+	//查询失败时不修改本地记录
+	pending, err := m.Pending(testAddr)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("Pending = %v, %v, want the tx kept", pending, err)
+	}
+}
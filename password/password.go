@@ -0,0 +1,154 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"github.com/howeyc/gopass"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var (
+	//口令来源中没有可用的口令
+	ErrNoPassword = errors.New("no password available")
+	//两次输入的口令不一致
+	ErrMismatch = errors.New("passwords do not match")
+)
+
+//口令来源, who为需要口令的账户或文件, 用于交互式提示
+type Provider interface {
+	Password(who string) (string, error)
+}
+
+//需要设置新口令时, 支持重复确认的来源实现该接口
+type NewPassworder interface {
+	NewPassword(who string) (string, error)
+}
+
+//获取新口令: 来源支持重复确认时要求输入两次, 否则与Password相同
+func New(p Provider, who string) (string, error) {
+	if np, ok := p.(NewPassworder); ok {
+		return np.NewPassword(who)
+	}
+	return p.Password(who)
+}
+
+//程序内回调, 将普通函数适配为Provider
+type Func func(who string) (string, error)
+
+func (f Func) Password(who string) (string, error) {
+	return f(who)
+}
+
+//在终端提示输入, 输入不回显
+type TTY struct{}
+
+func (TTY) Password(who string) (string, error) {
+	fmt.Println("Please input password for: ", who)
+	pass, err := gopass.GetPasswd()
+	if err != nil {
+		return "", err
+	}
+	return string(pass), nil
+}
+
+func (t TTY) NewPassword(who string) (string, error) {
+	pass, err := t.Password(who)
+	if err != nil {
+		return "", err
+	}
+	fmt.Println("Please repeat password: ")
+	repeat, err := gopass.GetPasswd()
+	if err != nil {
+		return "", err
+	}
+	if pass != string(repeat) {
+		return "", ErrMismatch
+	}
+	return pass, nil
+}
+
+//口令文件, 每行一个口令, 依次用于各次请求, 行数不足时重复使用最后一行
+type File struct {
+	lines []string
+	next  int
+}
+
+//读取口令文件, 忽略行尾的\r
+func NewFile(filename string) (*File, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimRight(string(content), "\r\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+	return &File{lines: lines}, nil
+}
+
+func (f *File) Password(who string) (string, error) {
+	if len(f.lines) == 0 {
+		return "", ErrNoPassword
+	}
+	pass := f.lines[len(f.lines)-1]
+	if f.next < len(f.lines) {
+		pass = f.lines[f.next]
+		f.next++
+	}
+	return pass, nil
+}
+
+//从环境变量读取口令, 每次请求返回同一口令
+type Env string
+
+func (e Env) Password(who string) (string, error) {
+	pass, ok := os.LookupEnv(string(e))
+	if !ok {
+		return "", fmt.Errorf("%w: environment variable %s is not set", ErrNoPassword, string(e))
+	}
+	return pass, nil
+}
+
+//从输入流逐行读取口令, 每次请求读取一行
+//逐字节读取, 不会多读属于后续提示的内容
+type Reader struct {
+	r io.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+//从标准输入读取口令
+func Stdin() *Reader {
+	return NewReader(os.Stdin)
+}
+
+func (r *Reader) Password(who string) (string, error) {
+	var (
+		line []byte
+		buf  [1]byte
+	)
+	for {
+		n, err := r.r.Read(buf[:])
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err == io.EOF {
+			if len(line) == 0 {
+				return "", fmt.Errorf("%w: end of input", ErrNoPassword)
+			}
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimRight(string(line), "\r"), nil
+}
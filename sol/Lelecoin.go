@@ -29,6 +29,23 @@ var (
 // LelecoinABI is the input ABI used to generate the binding from.
 const LelecoinABI = "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Approval\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Transfer\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"}],\"name\":\"allowance\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"approve\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"balanceOf\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"mint\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"symbol\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"totalSupply\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"transfer\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"transferFrom\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]"
 
+// LelecoinBin is the compiled bytecode used for deploying new contracts.
+var LelecoinBin = "0x608060405234801561001057600080fd5b506040805180820190915260088152672632b632b1b7b4b760c11b602082015260049061003d90826100f4565b50600380546001600160a01b031916331790556101b3565b634e487b7160e01b600052604160045260246000fd5b600181811c9082168061007f57607f821691505b60208210810361009f57634e487b7160e01b600052602260045260246000fd5b50919050565b601f8211156100ef57600081815260208120601f850160051c810160208610156100cc5750805b601f850160051c820191505b818110156100eb578281556001016100d8565b5050505b505050565b81516001600160401b0381111561010d5761010d610055565b6101218161011b845461006b565b846100a5565b602080601f831160018114610156576000841561013e5750858301515b600019600386901b1c1916600185901b1785556100eb565b600085815260208120601f198616915b8281101561018557888601518255948401946001909101908401610166565b50858210156101a35787850151600019600388901b60f8161c191681555b5050505050600190811b01905550565b6107f3806101c26000396000f3fe608060405234801561001057600080fd5b50600436106100885760003560e01c806370a082311161005b57806370a08231146100ef57806395d89b4114610118578063a9059cbb1461012d578063dd62ed3e1461014057600080fd5b8063095ea7b31461008d57806318160ddd146100b557806323b872dd146100c757806340c10f19146100da575b600080fd5b6100a061009b366004610645565b610179565b60405190151581526020015b60405180910390f35b6002545b6040519081526020016100ac565b6100a06100d536600461066f565b6101f4565b6100ed6100e8366004610645565b610352565b005b6100b96100fd3660046106ab565b6001600160a01b031660009081526020819052604090205490565b6101206103f1565b6040516100ac91906106c6565b6100a061013b366004610645565b61047f565b6100b961014e366004610714565b6001600160a01b03918216600090815260016020908152604080832093909416825291909152205490565b60006001600160a01b03831661018e57600080fd5b3360008181526001602090815260408083206001600160a01b03881680855290835292819020869055518581529192917f8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b92591015b60405180910390a35060015b92915050565b6001600160a01b03831660009081526020819052604081205482111561021957600080fd5b6001600160a01b038416600090815260016020908152604080832033845290915290205482111561024957600080fd5b6001600160a01b03831661025c57600080fd5b6001600160a01b03841660009081526020819052604090205461027f9083610542565b6001600160a01b0380861660009081526020819052604080822093909355908516815220546102ae908361058b565b6001600160a01b038085166000908152602081815260408083209490945591871681526001825282812033825290915220546102ea9083610542565b6001600160a01b03858116600081815260016020908152604080832033845282529182902094909455518581529186169290917fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef910160405180910390a35060019392505050565b6003546001600160a01b0316331461036957600080fd5b600254610376908261058b565b6002556001600160a01b03821660009081526020819052604090205461039c908261058b565b6001600160a01b038316600081815260208181526040808320949094559251848152919290917fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef910160405180910390a35050565b600480546103fe90610747565b80601f016020809104026020016040519081016040528092919081815260200182805461042a90610747565b80156104775780601f1061044c57610100808354040283529160200191610477565b820191906000526020600020905b81548152906001019060200180831161045a57829003601f168201915b505050505081565b3360009081526020819052604081205482111561049b57600080fd5b6001600160a01b0383166104ae57600080fd5b336000908152602081905260409020546104c89083610542565b33600090815260208190526040808220929092556001600160a01b038516815220546104f4908361058b565b6001600160a01b038416600081815260208181526040918290209390935551848152909133917fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef91016101e2565b600061058483836040518060400160405280601e81526020017f536166654d6174683a207375627472616374696f6e206f766572666c6f7700008152506105ef565b9392505050565b6000806105988385610797565b9050838110156105845760405162461bcd60e51b815260206004820152601b60248201527f536166654d6174683a206164646974696f6e206f766572666c6f77000000000060448201526064015b60405180910390fd5b600081848411156106135760405162461bcd60e51b81526004016105e691906106c6565b50600061062084866107aa565b95945050505050565b80356001600160a01b038116811461064057600080fd5b919050565b6000806040838503121561065857600080fd5b61066183610629565b946020939093013593505050565b60008060006060848603121561068457600080fd5b61068d84610629565b925061069b60208501610629565b9150604084013590509250925092565b6000602082840312156106bd57600080fd5b61058482610629565b600060208083528351808285015260005b818110156106f3578581018301518582016040015282016106d7565b506000604082860101526040601f19601f8301168501019250505092915050565b6000806040838503121561072757600080fd5b61073083610629565b915061073e60208401610629565b90509250929050565b600181811c9082168061075b57607f821691505b60208210810361077b57634e487b7160e01b600052602260045260246000fd5b50919050565b634e487b7160e01b600052601160045260246000fd5b808201808211156101ee576101ee610781565b818103818111156101ee576101ee61078156fea264697066735822122084b432e68c9851e6116370fe1da1ace0bec29ea6578a348d5ae68553c8d8eec564736f6c63430008150033"
+
+// DeployLelecoin deploys a new Ethereum contract, binding an instance of Lelecoin to it.
+func DeployLelecoin(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *Lelecoin, error) {
+	parsed, err := abi.JSON(strings.NewReader(LelecoinABI))
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(LelecoinBin), backend)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &Lelecoin{LelecoinCaller: LelecoinCaller{contract: contract}, LelecoinTransactor: LelecoinTransactor{contract: contract}, LelecoinFilterer: LelecoinFilterer{contract: contract}}, nil
+}
+
 // Lelecoin is an auto generated Go binding around an Ethereum contract.
 type Lelecoin struct {
 	LelecoinCaller     // Read-only binding to the contract
@@ -0,0 +1,7 @@
+package sol
+
+//Lelecoin.bin为contracts/Lelecoin.sol的solc输出, 编译器v0.8.21, 开启优化(runs=200), evm版本london
+//go-ethereum v1.10.4不支持shanghai引入的PUSH0, 不能使用solc 0.8.20起的默认evm版本
+//修改合约后在contracts目录重新编译并生成绑定
+//go:generate sh -c "cd ../contracts && solc --evm-version london --optimize --optimize-runs 200 --bin --overwrite -o ../sol Lelecoin.sol && rm -f ../sol/SafeMath.bin ../sol/IERC20.bin"
+//go:generate abigen --abi Lelecoin.abi --bin Lelecoin.bin --pkg sol --type Lelecoin --out Lelecoin.go
@@ -0,0 +1,184 @@
+package token
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"wallet/hdkeystore"
+	"wallet/sol"
+)
+
+//代币symbol、name与decimals的缓存文件, 离线时history等命令只能从这里获取代币信息
+const FileName = "tokens.json"
+
+//地址上没有合约代码
+var ErrNotContract = errors.New("no contract code at address")
+
+//ERC-20代币的基本信息
+type Info struct {
+	Address  common.Address `json:"address"`
+	Symbol   string         `json:"symbol"`
+	Name     string         `json:"name"`
+	Decimals uint8          `json:"decimals"`
+}
+
+//代币注册表, 按链ID与合约地址缓存从链上读取的代币信息
+type Registry struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewRegistry(dataDir string) *Registry {
+	return &Registry{
+		path: filepath.Join(dataDir, FileName),
+	}
+}
+
+//缓存键, 同一地址在不同链上可能是不同的合约
+func cacheKey(chainID *big.Int, addr common.Address) string {
+	return chainID.String() + ":" + addr.Hex()
+}
+
+//获取代币信息, 优先读取缓存, 缓存中没有时从链上读取并写入缓存
+func (r *Registry) Lookup(ctx context.Context, backend bind.ContractCaller, chainID *big.Int, addr common.Address) (*Info, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cache, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	key := cacheKey(chainID, addr)
+	if info, ok := cache[key]; ok {
+		return info, nil
+	}
+	info, err := Fetch(ctx, backend, addr)
+	if err != nil {
+		return nil, err
+	}
+	cache[key] = info
+	return info, r.save(cache)
+}
+
+//只读取缓存的代币信息, 用于离线场景, 缓存中没有时返回false
+func (r *Registry) Cached(chainID *big.Int, addr common.Address) (*Info, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cache, err := r.load()
+	if err != nil {
+		return nil, false, err
+	}
+	info, ok := cache[cacheKey(chainID, addr)]
+	return info, ok, nil
+}
+
+//所有缓存的代币信息
+func (r *Registry) load() (map[string]*Info, error) {
+	cache := make(map[string]*Info)
+	content, err := ioutil.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &cache); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+func (r *Registry) save(cache map[string]*Info) error {
+	content, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return hdkeystore.WriteKeyFile(r.path, content)
+}
+
+//从链上读取代币信息
+//name与decimals在ERC-20中是可选的, 合约未实现时name为空、decimals为0;
+//未实现symbol时以地址缩写代替
+func Fetch(ctx context.Context, backend bind.ContractCaller, addr common.Address) (*Info, error) {
+	code, err := backend.CodeAt(ctx, addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotContract, addr.Hex())
+	}
+	erc20ABI, err := abi.JSON(strings.NewReader(sol.ERC20ABI))
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{Address: addr}
+	if out, ok, err := callOptional(ctx, backend, erc20ABI, addr, "symbol"); err != nil {
+		return nil, err
+	} else if ok {
+		info.Symbol = out.(string)
+	} else {
+		info.Symbol = addr.Hex()[:10]
+	}
+	if out, ok, err := callOptional(ctx, backend, erc20ABI, addr, "name"); err != nil {
+		return nil, err
+	} else if ok {
+		info.Name = out.(string)
+	}
+	if out, ok, err := callOptional(ctx, backend, erc20ABI, addr, "decimals"); err != nil {
+		return nil, err
+	} else if ok {
+		info.Decimals = out.(uint8)
+	}
+	return info, nil
+}
+
+//调用无参数的只读方法, 合约未实现(执行回滚或无返回值)时返回false
+//限流、节点内部错误等其他错误以及无法解码的返回值均原样返回, 避免把错误的信息写入缓存
+func callOptional(ctx context.Context, backend bind.ContractCaller, contractABI abi.ABI, addr common.Address, method string) (interface{}, bool, error) {
+	data, err := contractABI.Pack(method)
+	if err != nil {
+		return nil, false, err
+	}
+	output, err := backend.CallContract(ctx, ethereum.CallMsg{To: &addr, Data: data}, nil)
+	if err != nil {
+		if isRevert(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to call %s of %s: %w", method, addr.Hex(), err)
+	}
+	if len(output) == 0 {
+		return nil, false, nil
+	}
+	values, err := contractABI.Unpack(method, output)
+	if err != nil || len(values) != 1 {
+		//早期代币(如MKR)的name与symbol返回bytes32而非string
+		if len(output) == 32 && contractABI.Methods[method].Outputs[0].Type.T == abi.StringTy {
+			return string(bytes.TrimRight(output, "\x00")), true, nil
+		}
+		return nil, false, fmt.Errorf("failed to decode %s of %s: %x", method, addr.Hex(), output)
+	}
+	return values[0], true, nil
+}
+
+//调用是否因合约执行回滚而失败, 未实现的方法会回滚(早期合约为invalid opcode)
+//geth对带回滚数据的错误返回DataError, 其余节点与服务商按错误信息判断
+func isRevert(err error) bool {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) && dataErr.ErrorData() != nil {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "execution reverted") || strings.Contains(msg, "invalid opcode") || strings.Contains(msg, "vm execution error")
+}
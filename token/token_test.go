@@ -0,0 +1,135 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"wallet/sol"
+)
+
+//JSON-RPC错误, 实现rpc.Error
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e rpcError) Error() string  { return e.msg }
+func (e rpcError) ErrorCode() int { return e.code }
+
+//模拟代币合约, 按方法名返回结果或错误
+type fakeCaller struct {
+	outputs map[string][]byte
+	errs    map[string]error
+}
+
+func (f *fakeCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{0x60}, nil
+}
+
+func (f *fakeCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	erc20ABI, _ := abi.JSON(strings.NewReader(sol.ERC20ABI))
+	method, err := erc20ABI.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	if err := f.errs[method.Name]; err != nil {
+		return nil, err
+	}
+	return f.outputs[method.Name], nil
+}
+
+func pack(t *testing.T, method string, v interface{}) []byte {
+	erc20ABI, err := abi.JSON(strings.NewReader(sol.ERC20ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := erc20ABI.Methods[method].Outputs.Pack(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+var testToken = common.HexToAddress("0x967b24fe559fCaAA57d5F3Fa033517fdaA005bD6")
+
+func TestLookupOptionalMethods(t *testing.T) {
+	caller := &fakeCaller{
+		outputs: map[string][]byte{"symbol": pack(t, "symbol", "LELE")},
+		errs: map[string]error{
+			"name":     rpcError{3, "execution reverted"},
+			"decimals": rpcError{-32000, "execution reverted"},
+		},
+	}
+	dir, err := ioutil.TempDir("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := NewRegistry(dir)
+	info, err := r.Lookup(context.Background(), caller, big.NewInt(1), testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Symbol != "LELE" || info.Name != "" || info.Decimals != 0 {
+		t.Fatalf("info = %+v", info)
+	}
+	if _, ok, _ := r.Cached(big.NewInt(1), testToken); !ok {
+		t.Fatal("token not cached")
+	}
+}
+
+//限流等非回滚错误不能当作方法未实现, 也不能写入缓存
+func TestLookupDoesNotCacheTransientErrors(t *testing.T) {
+	rateLimited := rpcError{-32005, "daily request count exceeded, request rate limited"}
+	caller := &fakeCaller{
+		outputs: map[string][]byte{"symbol": pack(t, "symbol", "LELE"), "name": pack(t, "name", "Lelecoin")},
+		errs:    map[string]error{"decimals": rateLimited},
+	}
+	dir, err := ioutil.TempDir("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := NewRegistry(dir)
+	if _, err := r.Lookup(context.Background(), caller, big.NewInt(1), testToken); !errors.Is(err, rateLimited) {
+		t.Fatalf("Lookup error = %v, want %v", err, rateLimited)
+	}
+	if _, err := os.Stat(filepath.Join(dir, FileName)); !os.IsNotExist(err) {
+		t.Fatalf("%s written after a failed lookup", FileName)
+	}
+	//恢复后读取到正确的decimals
+	delete(caller.errs, "decimals")
+	caller.outputs["decimals"] = pack(t, "decimals", uint8(18))
+	info, err := r.Lookup(context.Background(), caller, big.NewInt(1), testToken)
+	if err != nil || info.Decimals != 18 {
+		t.Fatalf("Lookup = %+v, %v, want 18 decimals", info, err)
+	}
+}
+
+func TestLookupBytes32Symbol(t *testing.T) {
+	symbol := common.RightPadBytes([]byte("MKR"), 32)
+	caller := &fakeCaller{
+		outputs: map[string][]byte{"symbol": symbol, "decimals": pack(t, "decimals", uint8(18))},
+	}
+	info, err := Fetch(context.Background(), caller, testToken)
+	if err != nil || info.Symbol != "MKR" {
+		t.Fatalf("Fetch = %+v, %v, want symbol MKR", info, err)
+	}
+}
+
+func TestLookupUndecodableOutput(t *testing.T) {
+	caller := &fakeCaller{
+		outputs: map[string][]byte{"symbol": pack(t, "symbol", "LELE"), "decimals": {0x01, 0x02}},
+	}
+	if _, err := Fetch(context.Background(), caller, testToken); err == nil {
+		t.Fatal("Fetch accepted undecodable decimals")
+	}
+}